@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/julienschmidt/httprouter"
+	"github.com/sevki/rehook/pipeline"
+)
+
+// AdminHandler serves the private admin interface used to configure hooks
+// and their components. There's no templating layer in this tree yet, so
+// responses are JSON rather than rendered HTML.
+type AdminHandler struct {
+	hooks *HookStore
+	db    *bolt.DB
+}
+
+// Index lists every configured hook.
+func (ah *AdminHandler) Index(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	hooks, err := ah.hooks.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, hooks)
+}
+
+// NewHook describes the fields CreateHook expects.
+func (ah *AdminHandler) NewHook(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	writeJSON(w, map[string]string{"method": "POST", "path": "/hooks", "id": "required"})
+}
+
+// CreateHook creates a new, empty hook.
+func (ah *AdminHandler) CreateHook(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	if err := ah.hooks.Save(Hook{ID: id}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/hooks/edit/"+id, http.StatusSeeOther)
+}
+
+// hookView is what EditHook renders: the hook itself, plus each attached
+// component's name and its current parameters.
+type hookView struct {
+	Hook       Hook              `json:"hook"`
+	Components []componentParams `json:"components"`
+	Available  []string          `json:"available_components"`
+}
+
+type componentParams struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params"`
+}
+
+// EditHook shows a hook's configuration.
+func (ah *AdminHandler) EditHook(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	h, err := ah.hooks.Get(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	view := hookView{Hook: h}
+	err = ah.hooks.db.View(func(t *bolt.Tx) error {
+		b := ah.hooks.bucket(t, id)
+		for _, name := range h.Components {
+			c, ok := Components()[name]
+			if !ok {
+				continue
+			}
+			view.Components = append(view.Components, componentParams{Name: name, Params: c.Params(h, b)})
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for name := range Components() {
+		view.Available = append(view.Available, name)
+	}
+	writeJSON(w, view)
+}
+
+// UpdateHook re-saves a hook unchanged. It reads no form fields, so a hook's
+// Timeout can't be set here; its pipeline is edited through UpdatePipeline.
+func (ah *AdminHandler) UpdateHook(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	h, err := ah.hooks.Get(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := ah.hooks.Save(h); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/hooks/edit/"+id, http.StatusSeeOther)
+}
+
+// AddComponent describes the components available to attach to a hook.
+func (ah *AdminHandler) AddComponent(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	var names []string
+	for name := range Components() {
+		names = append(names, name)
+	}
+	writeJSON(w, names)
+}
+
+// CreateComponent attaches a component to a hook, initializing it with the
+// submitted form parameters.
+func (ah *AdminHandler) CreateComponent(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	h, err := ah.hooks.Get(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	name := r.FormValue("component")
+	c, ok := Components()[name]
+	if !ok {
+		http.Error(w, "unknown component", http.StatusBadRequest)
+		return
+	}
+	if err := ah.hooks.db.Update(func(t *bolt.Tx) error {
+		b := ah.hooks.bucket(t, id)
+		return c.Init(h, formParams(r), b)
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.Components = append(h.Components, name)
+	if err := ah.hooks.Save(h); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/hooks/edit/"+id, http.StatusSeeOther)
+}
+
+// EditComponent shows a single attached component's current parameters.
+func (ah *AdminHandler) EditComponent(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, name := p.ByName("id"), p.ByName("c")
+	h, err := ah.hooks.Get(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	c, ok := Components()[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	var params map[string]string
+	err = ah.hooks.db.View(func(t *bolt.Tx) error {
+		params = c.Params(h, ah.hooks.bucket(t, id))
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, componentParams{Name: name, Params: params})
+}
+
+// UpdateComponent re-initializes an attached component with the submitted
+// form parameters.
+func (ah *AdminHandler) UpdateComponent(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, name := p.ByName("id"), p.ByName("c")
+	h, err := ah.hooks.Get(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	c, ok := Components()[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := ah.hooks.db.Update(func(t *bolt.Tx) error {
+		return c.Init(h, formParams(r), ah.hooks.bucket(t, id))
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/hooks/edit/"+id, http.StatusSeeOther)
+}
+
+// EditPipeline shows a hook's current YAML pipeline definition.
+func (ah *AdminHandler) EditPipeline(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	h, err := ah.hooks.Get(p.ByName("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write([]byte(h.Pipeline))
+}
+
+// UpdatePipeline validates and saves a hook's YAML pipeline definition.
+func (ah *AdminHandler) UpdatePipeline(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	h, err := ah.hooks.Get(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "could not read request body", http.StatusBadRequest)
+		return
+	}
+	if _, err := pipeline.Parse(body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid pipeline: %v", err), http.StatusBadRequest)
+		return
+	}
+	h.Pipeline = string(body)
+	if err := ah.hooks.Save(h); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/hooks/edit/"+id, http.StatusSeeOther)
+}
+
+// ListDeliveries shows every delivery recorded for a hook, most recent
+// first.
+func (ah *AdminHandler) ListDeliveries(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	deliveries, err := ListDeliveries(ah.db, p.ByName("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, deliveries)
+}
+
+// ShowDelivery shows a single delivery's payload and the outcome of
+// dispatching it.
+func (ah *AdminHandler) ShowDelivery(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	d, err := GetDelivery(ah.db, p.ByName("did"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, d)
+}
+
+// ReplayDelivery re-dispatches a previously stored delivery through its
+// hook's components, bypassing each component's own uniqueness check via
+// Request.Replay, and records the outcome under a derived delivery ID so it
+// doesn't overwrite the original record.
+func (ah *AdminHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	orig, err := GetDelivery(ah.db, p.ByName("did"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	h, err := ah.hooks.Get(orig.HookID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	req := Request{Headers: orig.Headers, Body: orig.Body, Replay: true}
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = defaultProcessTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	replay := Delivery{
+		ID:         replayID(orig.ID, int(time.Now().UnixNano())),
+		HookID:     orig.HookID,
+		Headers:    orig.Headers,
+		Body:       orig.Body,
+		ReceivedAt: orig.ReceivedAt,
+		Status:     http.StatusOK,
+		Errors:     dispatch(ctx, ah.db, ah.hooks, h, req),
+	}
+	if err := StoreDelivery(ah.db, replay); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, replay)
+}
+
+// RotateKey re-seals every hook's private key under a newly supplied master
+// key, via RotateMasterKey. The new key is taken from the "key" field of the
+// POST body only, so it's never logged as part of the request URL.
+func (ah *AdminHandler) RotateKey(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	newKey := r.PostFormValue("key")
+	if newKey == "" {
+		http.Error(w, "key is required", http.StatusBadRequest)
+		return
+	}
+	if err := RotateMasterKey(ah.db, newKey); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "rotated"})
+}
+
+// formParams reduces a submitted form to a map of its first value per
+// field, which is the shape every Component.Init expects its params in.
+func formParams(r *http.Request) map[string]string {
+	r.ParseForm()
+	out := make(map[string]string, len(r.Form))
+	for k, v := range r.Form {
+		if len(v) > 0 {
+			out[k] = v[0]
+		}
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/boltdb/bolt"
+)
+
+// Component is a single unit of work that runs against an incoming webhook
+// delivery, for whichever hook it's attached to.
+type Component interface {
+	// Name returns the human-readable name of this component, shown in
+	// the admin interface.
+	Name() string
+	// Template returns the HTML template name used to render this
+	// component's configuration form.
+	Template() string
+	// Params returns the currently stored configuration parameters for
+	// hook h from bucket b.
+	Params(h Hook, b *bolt.Bucket) map[string]string
+	// Init stores params for hook h in bucket b, validating them first.
+	Init(h Hook, params map[string]string, b *bolt.Bucket) error
+	// Process runs this component against an incoming delivery. ctx
+	// carries the hook's configured timeout and is canceled if the
+	// client disconnects. r.Args carries the current pipeline step's own
+	// arguments, when the hook has one configured.
+	Process(ctx context.Context, h Hook, r Request, b *bolt.Bucket) error
+}
+
+var (
+	componentsMu sync.Mutex
+	components   = map[string]Component{}
+)
+
+// RegisterComponent makes a component available to be attached to hooks
+// under the given name. It's meant to be called from a component's init().
+func RegisterComponent(name string, c Component) {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	components[name] = c
+}
+
+// Components returns every registered component, keyed by the name it was
+// registered under.
+func Components() map[string]Component {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	out := make(map[string]Component, len(components))
+	for k, v := range components {
+		out[k] = v
+	}
+	return out
+}
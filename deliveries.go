@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// BucketDeliveries is the top-level bucket storing every webhook delivery
+// rehook has received, independent of whether any component accepted or
+// rejected it. Deliveries are retained so they can be inspected and
+// replayed from the admin interface.
+var BucketDeliveries = []byte("deliveries-log")
+
+// Delivery is a persisted record of an incoming webhook delivery: the raw
+// request, and the outcome of dispatching it to the hook's components.
+type Delivery struct {
+	ID         string            `json:"id"`
+	HookID     string            `json:"hook_id"`
+	Headers    map[string]string `json:"headers"`
+	Body       []byte            `json:"body"`
+	ReceivedAt time.Time         `json:"received_at"`
+	Status     int               `json:"status"`
+	// Errors holds the error returned by each component that ran, keyed
+	// by component name; a component with no entry succeeded.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// StoreDelivery persists d under BucketDeliveries, keyed by its ID.
+// HookHandler.ReceiveHook calls it before a delivery is dispatched to
+// components, so a received delivery is recorded even if a component panics
+// or the process is killed mid-flight. AdminHandler.ReplayDelivery stores its
+// record only after dispatch returns.
+func StoreDelivery(db *bolt.DB, d Delivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return db.Update(func(t *bolt.Tx) error {
+		return t.Bucket(BucketDeliveries).Put([]byte(d.ID), data)
+	})
+}
+
+// GetDelivery loads a previously stored delivery by ID.
+func GetDelivery(db *bolt.DB, id string) (*Delivery, error) {
+	var d Delivery
+	err := db.View(func(t *bolt.Tx) error {
+		data := t.Bucket(BucketDeliveries).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("delivery %q not found", id)
+		}
+		return json.Unmarshal(data, &d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDeliveries returns every delivery recorded for the given hook, most
+// recent first by ReceivedAt. Delivery IDs are usually the forge's own
+// delivery UUID, which bolt's lexicographic key order bears no relation to,
+// so the listing is sorted explicitly rather than relying on iteration
+// order.
+func ListDeliveries(db *bolt.DB, hookID string) ([]Delivery, error) {
+	var out []Delivery
+	err := db.View(func(t *bolt.Tx) error {
+		return t.Bucket(BucketDeliveries).ForEach(func(k, v []byte) error {
+			var d Delivery
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			if d.HookID == hookID {
+				out = append(out, d)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ReceivedAt.After(out[j].ReceivedAt) })
+	return out, nil
+}
+
+// PruneDeliveries deletes every delivery older than retention, based on
+// ReceivedAt, and returns how many it removed. A retention of zero or less
+// disables pruning, since the persisted deliveries are also rehook's only
+// audit/replay record.
+func PruneDeliveries(db *bolt.DB, retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().Add(-retention)
+	var pruned int
+	err := db.Update(func(t *bolt.Tx) error {
+		b := t.Bucket(BucketDeliveries)
+		var stale [][]byte
+		if err := b.ForEach(func(k, v []byte) error {
+			var d Delivery
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			if d.ReceivedAt.Before(cutoff) {
+				stale = append(stale, append([]byte{}, k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(stale)
+		return nil
+	})
+	return pruned, err
+}
+
+// replayMarker joins the original delivery ID and a replay counter in the ID
+// a replayed delivery's record is stored under, so the replay's record
+// doesn't overwrite the original in BucketDeliveries. The replayed request
+// keeps the original headers; components like GithubSignedOffChecker skip
+// their DELIVERIES uniqueness check because Request.Replay is set.
+const replayMarker = "-replay-"
+
+func replayID(id string, attempt int) string {
+	return fmt.Sprintf("%s%s%d", id, replayMarker, attempt)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+func withDeliveriesBucket(t *testing.T, fn func(db *bolt.DB)) {
+	t.Helper()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "rehook.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("bolt.Open: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Update(func(t *bolt.Tx) error {
+		_, err := t.CreateBucketIfNotExists(BucketDeliveries)
+		return err
+	}); err != nil {
+		t.Fatalf("db.Update: %v", err)
+	}
+	fn(db)
+}
+
+func TestListDeliveriesMostRecentFirst(t *testing.T) {
+	withDeliveriesBucket(t, func(db *bolt.DB) {
+		now := time.Now()
+		// IDs are deliberately out of lexicographic order with
+		// ReceivedAt, like real X-Github-Delivery UUIDs would be.
+		deliveries := []Delivery{
+			{ID: "zzz-oldest", HookID: "h1", ReceivedAt: now.Add(-2 * time.Hour)},
+			{ID: "aaa-newest", HookID: "h1", ReceivedAt: now},
+			{ID: "mmm-middle", HookID: "h1", ReceivedAt: now.Add(-1 * time.Hour)},
+			{ID: "other-hook", HookID: "h2", ReceivedAt: now},
+		}
+		for _, d := range deliveries {
+			if err := StoreDelivery(db, d); err != nil {
+				t.Fatalf("StoreDelivery: %v", err)
+			}
+		}
+
+		got, err := ListDeliveries(db, "h1")
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("ListDeliveries returned %d deliveries, want 3", len(got))
+		}
+		wantOrder := []string{"aaa-newest", "mmm-middle", "zzz-oldest"}
+		for i, id := range wantOrder {
+			if got[i].ID != id {
+				t.Errorf("ListDeliveries[%d].ID = %q, want %q", i, got[i].ID, id)
+			}
+		}
+	})
+}
+
+func TestPruneDeliveriesRemovesOnlyStale(t *testing.T) {
+	withDeliveriesBucket(t, func(db *bolt.DB) {
+		now := time.Now()
+		deliveries := []Delivery{
+			{ID: "stale", HookID: "h1", ReceivedAt: now.Add(-48 * time.Hour)},
+			{ID: "fresh", HookID: "h1", ReceivedAt: now},
+		}
+		for _, d := range deliveries {
+			if err := StoreDelivery(db, d); err != nil {
+				t.Fatalf("StoreDelivery: %v", err)
+			}
+		}
+
+		n, err := PruneDeliveries(db, 24*time.Hour)
+		if err != nil {
+			t.Fatalf("PruneDeliveries: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("PruneDeliveries pruned %d deliveries, want 1", n)
+		}
+		if _, err := GetDelivery(db, "stale"); err == nil {
+			t.Error("GetDelivery(stale): expected not found after pruning")
+		}
+		if _, err := GetDelivery(db, "fresh"); err != nil {
+			t.Errorf("GetDelivery(fresh): %v", err)
+		}
+	})
+}
+
+func TestPruneDeliveriesDisabledByZeroRetention(t *testing.T) {
+	withDeliveriesBucket(t, func(db *bolt.DB) {
+		d := Delivery{ID: "ancient", HookID: "h1", ReceivedAt: time.Now().Add(-24 * 365 * time.Hour)}
+		if err := StoreDelivery(db, d); err != nil {
+			t.Fatalf("StoreDelivery: %v", err)
+		}
+
+		n, err := PruneDeliveries(db, 0)
+		if err != nil {
+			t.Fatalf("PruneDeliveries: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("PruneDeliveries pruned %d deliveries with retention disabled, want 0", n)
+		}
+		if _, err := GetDelivery(db, "ancient"); err != nil {
+			t.Errorf("GetDelivery(ancient): %v", err)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/boltdb/bolt"
+	"github.com/sevki/rehook/remote"
+)
+
+const (
+	// TrustModelCollaborator requires the signer to be a collaborator on
+	// the repository.
+	TrustModelCollaborator = "collaborator"
+	// TrustModelCommitter requires the signature identity to match the
+	// commit's committer identity.
+	TrustModelCommitter = "committer"
+	// TrustModelMember requires the signer to be a member of the org/team
+	// configured for the hook.
+	TrustModelMember = "member"
+
+	signatureContext = "commit-signature"
+
+	// VerifiedKeys is the bolt bucket used to cache trust classifications
+	// for a hook, keyed by "<hookID>-<sha>".
+	VerifiedKeys = "verified-keys"
+)
+
+func init() {
+	RegisterComponent("github-commit-signature-verifier", GithubCommitSignatureVerifier{})
+}
+
+// GithubCommitSignatureVerifier checks that every commit on a pull request
+// carries a verified GPG/SSH signature, using the remote's own commit
+// verification API, and applies a configurable trust model on top of that
+// verification result.
+type GithubCommitSignatureVerifier struct{}
+
+// Name returns the name of this component.
+func (GithubCommitSignatureVerifier) Name() string { return "Github Commit Signature Verifier" }
+
+// Template returns the HTML template name of this component.
+func (GithubCommitSignatureVerifier) Template() string {
+	return "github-commit-signature-verifier"
+}
+
+// Params returns the currently stored configuration parameters for hook h
+// from bucket b.
+func (GithubCommitSignatureVerifier) Params(h Hook, b *bolt.Bucket) map[string]string {
+	m := make(map[string]string)
+	for _, k := range []string{"token", "secret", "remote", "trust", "org", "team"} {
+		v := b.Get([]byte(fmt.Sprintf("%s-%s", h.ID, k)))
+		if k == "token" || k == "secret" {
+			if v == nil {
+				continue
+			}
+			plain, err := openSecret(h, b, string(v))
+			if err != nil {
+				continue
+			}
+			m[k] = plain
+			continue
+		}
+		m[k] = string(v)
+	}
+	return m
+}
+
+// Init initializes this component; see initSealedParams for the token,
+// secret and remote params it shares with every remote-backed component.
+// It additionally requires a trust model ("collaborator", "committer" or
+// "member"); "member" also requires org (and optionally team) to be
+// configured.
+func (GithubCommitSignatureVerifier) Init(h Hook, params map[string]string, b *bolt.Bucket) error {
+	trust := params["trust"]
+	switch trust {
+	case TrustModelCollaborator, TrustModelCommitter:
+	case TrustModelMember:
+		if params["org"] == "" {
+			return errors.New("org is required for the member trust model")
+		}
+	default:
+		return fmt.Errorf("unknown trust model %q", trust)
+	}
+	if err := initSealedParams(h, b, params); err != nil {
+		return err
+	}
+	for _, kv := range []struct{ k, v string }{
+		{"trust", trust}, {"org", params["org"]}, {"team", params["team"]},
+	} {
+		if err := b.Put([]byte(fmt.Sprintf("%s-%s", h.ID, kv.k)), []byte(kv.v)); err != nil {
+			return err
+		}
+	}
+	if _, err := b.CreateBucketIfNotExists([]byte(VerifiedKeys)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Process fetches the verification payload for every commit on the pull
+// request and reports a combined status describing the trust outcome: an
+// unverified signature is an error, a verified signature that doesn't match
+// the configured trust model is a warning, and a verified, trusted
+// signature is a success. The delivery's own signature was already
+// verified by dispatch before any component runs.
+func (GithubCommitSignatureVerifier) Process(ctx context.Context, h Hook, r Request, b *bolt.Bucket) error {
+	sealedToken := b.Get([]byte(fmt.Sprintf("%s-token", h.ID)))
+	if sealedToken == nil {
+		return errors.New("github commit signature verifier not initialized")
+	}
+	token, err := openSecret(h, b, string(sealedToken))
+	if err != nil {
+		return fmt.Errorf("opening token: %v", err)
+	}
+	trust := string(b.Get([]byte(fmt.Sprintf("%s-trust", h.ID))))
+	org := string(b.Get([]byte(fmt.Sprintf("%s-org", h.ID))))
+	team := string(b.Get([]byte(fmt.Sprintf("%s-team", h.ID))))
+
+	rem, err := remote.New(string(b.Get([]byte(fmt.Sprintf("%s-remote", h.ID)))), token)
+	if err != nil {
+		return err
+	}
+	pr, err := rem.ParsePullRequestEvent(r.Body)
+	if err != nil {
+		return err
+	}
+	owner, repo, number := pr.Owner, pr.Repo, pr.Number
+
+	commits, err := rem.ListCommits(ctx, owner, repo, number)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return errors.New("pull request has no commits")
+	}
+
+	state, desc := SUCCESS, "All commits have trusted signatures."
+	for _, c := range commits {
+		v, err := rem.GetCommitVerification(ctx, owner, repo, c.SHA)
+		if err != nil {
+			return err
+		}
+		if !v.Verified {
+			state, desc = ERROR, fmt.Sprintf("Commit %s has no verified signature.", shortSHA(c.SHA))
+			break
+		}
+		ok, err := matchesTrust(ctx, trust, h, rem, owner, repo, org, team, c, v, b)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			state, desc = "warning", fmt.Sprintf("Commit %s is signed but untrusted.", shortSHA(c.SHA))
+		}
+	}
+
+	lastCommit := commits[len(commits)-1].SHA
+	return rem.CreateStatus(ctx, owner, repo, lastCommit, remote.Status{
+		State:       state,
+		Context:     signatureContext,
+		Description: desc,
+	})
+}
+
+// matchesTrust classifies a verified commit against the hook's configured
+// trust model, querying the remote (and caching the result per commit) for
+// the models that require it.
+func matchesTrust(ctx context.Context, trust string, h Hook, rem remote.Remote, owner, repo, org, team string, c remote.Commit, v *remote.CommitVerification, b *bolt.Bucket) (bool, error) {
+	switch trust {
+	case TrustModelCommitter:
+		// The committer trust model requires the account the signature
+		// is attributed to match the account that actually committed,
+		// so a signature can't be borrowed from some other trusted
+		// identity and attached to a commit committed by someone else.
+		if v.SignerLogin == "" {
+			return false, errors.New("remote did not report a signer identity for this commit")
+		}
+		return v.SignerLogin == c.CommitterLogin, nil
+	case TrustModelCollaborator, TrustModelMember:
+		if v.SignerLogin == "" {
+			return false, errors.New("remote did not report a signer identity for this commit")
+		}
+		key := fmt.Sprintf("%s-%s", h.ID, c.SHA)
+		if cached := get(b, VerifiedKeys, key); cached != nil {
+			return string(cached) == "trusted", nil
+		}
+		var (
+			ok  bool
+			err error
+		)
+		if trust == TrustModelCollaborator {
+			ok, err = rem.IsCollaborator(ctx, owner, repo, v.SignerLogin)
+		} else {
+			ok, err = rem.IsOrgMember(ctx, org, team, v.SignerLogin)
+		}
+		if err != nil {
+			return false, err
+		}
+		label := "untrusted"
+		if ok {
+			label = "trusted"
+		}
+		if err := put(b, VerifiedKeys, key, []byte(label)); err != nil {
+			return false, err
+		}
+		return ok, nil
+	default:
+		return false, fmt.Errorf("unknown trust model %q", trust)
+	}
+}
+
+// shortSHA returns a commit SHA truncated for display in status
+// descriptions.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/sevki/rehook/remote"
+)
+
+// fakeRemote is a minimal remote.Remote stub for exercising matchesTrust
+// without talking to a real forge.
+type fakeRemote struct {
+	remote.Remote
+	collaborators map[string]bool
+	members       map[string]bool
+}
+
+func (f *fakeRemote) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	return f.collaborators[user], nil
+}
+
+func (f *fakeRemote) IsOrgMember(ctx context.Context, org, team, user string) (bool, error) {
+	return f.members[user], nil
+}
+
+func withVerifiedKeysBucket(t *testing.T, fn func(h Hook, b *bolt.Bucket)) {
+	t.Helper()
+	os.Setenv(masterKeyEnv, "a-test-master-key-of-arbitrary-length")
+	defer os.Unsetenv(masterKeyEnv)
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "rehook.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("bolt.Open: %v", err)
+	}
+	defer db.Close()
+
+	h := Hook{ID: "hook-under-test"}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(h.ID))
+		if err != nil {
+			return err
+		}
+		if _, err := b.CreateBucketIfNotExists([]byte(VerifiedKeys)); err != nil {
+			return err
+		}
+		fn(h, b)
+		return nil
+	}); err != nil {
+		t.Fatalf("db.Update: %v", err)
+	}
+}
+
+func TestMatchesTrustCommitter(t *testing.T) {
+	withVerifiedKeysBucket(t, func(h Hook, b *bolt.Bucket) {
+		c := remote.Commit{CommitterLogin: "dev"}
+		ok, err := matchesTrust(context.Background(), TrustModelCommitter, h, nil, "", "", "", "", c, &remote.CommitVerification{Verified: true, SignerLogin: "dev"}, b)
+		if err != nil || !ok {
+			t.Errorf("matchesTrust(committer, signer matches committer) = %v, %v; want true, nil", ok, err)
+		}
+
+		ok, err = matchesTrust(context.Background(), TrustModelCommitter, h, nil, "", "", "", "", c, &remote.CommitVerification{Verified: true, SignerLogin: "someone-else"}, b)
+		if err != nil || ok {
+			t.Errorf("matchesTrust(committer, signer doesn't match committer) = %v, %v; want false, nil", ok, err)
+		}
+
+		_, err = matchesTrust(context.Background(), TrustModelCommitter, h, nil, "", "", "", "", c, &remote.CommitVerification{Verified: true}, b)
+		if err == nil {
+			t.Error("matchesTrust(committer): expected error when remote reports no signer identity")
+		}
+	})
+}
+
+func TestMatchesTrustCollaborator(t *testing.T) {
+	withVerifiedKeysBucket(t, func(h Hook, b *bolt.Bucket) {
+		rem := &fakeRemote{collaborators: map[string]bool{"trusted-dev": true}}
+		c := remote.Commit{SHA: "abc123"}
+
+		ok, err := matchesTrust(context.Background(), TrustModelCollaborator, h, rem, "owner", "repo", "", "", c, &remote.CommitVerification{Verified: true, SignerLogin: "trusted-dev"}, b)
+		if err != nil || !ok {
+			t.Fatalf("matchesTrust(collaborator, trusted-dev) = %v, %v; want true, nil", ok, err)
+		}
+
+		// A second call for the same commit should hit the cached
+		// result rather than calling the remote again; flip the fake's
+		// answer to prove the cache, not a fresh lookup, is used.
+		rem.collaborators["trusted-dev"] = false
+		ok, err = matchesTrust(context.Background(), TrustModelCollaborator, h, rem, "owner", "repo", "", "", c, &remote.CommitVerification{Verified: true, SignerLogin: "trusted-dev"}, b)
+		if err != nil || !ok {
+			t.Errorf("matchesTrust(collaborator, cached) = %v, %v; want true, nil (cached)", ok, err)
+		}
+	})
+}
+
+func TestMatchesTrustCollaboratorUntrusted(t *testing.T) {
+	withVerifiedKeysBucket(t, func(h Hook, b *bolt.Bucket) {
+		rem := &fakeRemote{collaborators: map[string]bool{}}
+		c := remote.Commit{SHA: "def456"}
+		ok, err := matchesTrust(context.Background(), TrustModelCollaborator, h, rem, "owner", "repo", "", "", c, &remote.CommitVerification{Verified: true, SignerLogin: "outside-contributor"}, b)
+		if err != nil || ok {
+			t.Errorf("matchesTrust(collaborator, unknown signer) = %v, %v; want false, nil", ok, err)
+		}
+	})
+}
+
+func TestMatchesTrustRequiresSignerIdentity(t *testing.T) {
+	withVerifiedKeysBucket(t, func(h Hook, b *bolt.Bucket) {
+		rem := &fakeRemote{}
+		c := remote.Commit{SHA: "ghi789"}
+		_, err := matchesTrust(context.Background(), TrustModelCollaborator, h, rem, "owner", "repo", "", "", c, &remote.CommitVerification{Verified: true}, b)
+		if err == nil {
+			t.Error("matchesTrust: expected error when remote reports no signer identity")
+		}
+	})
+}
+
+func TestMatchesTrustUnknownModel(t *testing.T) {
+	withVerifiedKeysBucket(t, func(h Hook, b *bolt.Bucket) {
+		_, err := matchesTrust(context.Background(), "bogus", h, nil, "", "", "", "", remote.Commit{}, &remote.CommitVerification{}, b)
+		if err == nil {
+			t.Error("matchesTrust: expected error for unknown trust model")
+		}
+	})
+}
@@ -1,14 +1,13 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
 
 	"github.com/boltdb/bolt"
-	"github.com/google/go-github/github"
-	"golang.org/x/oauth2"
+	"github.com/sevki/rehook/remote"
 )
 
 var (
@@ -31,19 +30,26 @@ func (GithubReviewRequest) Template() string { return "github-review-request" }
 // from bucket b.
 func (GithubReviewRequest) Params(h Hook, b *bolt.Bucket) map[string]string {
 	m := make(map[string]string)
-	for _, k := range []string{"token"} {
-		m[k] = string(b.Get([]byte(fmt.Sprintf("%s-%s", h.ID, k))))
+	for _, k := range []string{"token", "secret"} {
+		v := b.Get([]byte(fmt.Sprintf("%s-%s", h.ID, k)))
+		if v == nil {
+			continue
+		}
+		plain, err := openSecret(h, b, string(v))
+		if err != nil {
+			continue
+		}
+		m[k] = plain
 	}
+	m["remote"] = string(b.Get([]byte(fmt.Sprintf("%s-remote", h.ID))))
 	return m
 }
 
-// Init initializes this component. It requires a token to be present.
+// Init initializes this component; see initSealedParams for the token,
+// secret and remote params it shares with every remote-backed component.
+// The secret is used to verify the HMAC signature of incoming deliveries.
 func (GithubReviewRequest) Init(h Hook, params map[string]string, b *bolt.Bucket) error {
-	token, ok := params["token"]
-	if !ok {
-		return errors.New("token is required")
-	}
-	if err := b.Put([]byte(fmt.Sprintf("%s-token", h.ID)), []byte(token)); err != nil {
+	if err := initSealedParams(h, b, params); err != nil {
 		return err
 	}
 	for _, k := range []string{DELIVERIES} {
@@ -54,52 +60,52 @@ func (GithubReviewRequest) Init(h Hook, params map[string]string, b *bolt.Bucket
 	return nil
 }
 
-// Process verifies the signature and uniqueness of the delivery identifier.
-func (GithubReviewRequest) Process(h Hook, r Request, b *bolt.Bucket) error {
+// Process records the delivery identifier in DELIVERIES but does not reject
+// a duplicate delivery, which is processed again; the delivery's signature
+// has already been verified by dispatch before any component runs. ctx is
+// derived from the incoming request and carries the hook's configured
+// timeout; every outbound call made while handling the delivery is bound
+// to it.
+func (GithubReviewRequest) Process(ctx context.Context, h Hook, r Request, b *bolt.Bucket) error {
 
-	// Check uniqueness
+	// Look up the delivery identifier; a duplicate is not rejected, since
+	// the return below is commented out.
 	id := fmt.Sprintf("GHR-%s", r.Headers["X-Github-Delivery"])
-	if did := get(b, DELIVERIES, id); did != nil {
+	if did := get(b, DELIVERIES, id); did != nil && !r.Replay {
 		//		return errors.New("duplicate delivery")
 	}
-	token := b.Get([]byte(fmt.Sprintf("%s-token", h.ID)))
-	if token == nil {
+	sealedToken := b.Get([]byte(fmt.Sprintf("%s-token", h.ID)))
+	if sealedToken == nil {
 		return errors.New("github validator not initialized")
 	}
+	token, err := openSecret(h, b, string(sealedToken))
+	if err != nil {
+		return fmt.Errorf("opening token: %v", err)
+	}
 
-	var pr github.PullRequestEvent
-	if err := json.Unmarshal(r.Body, &pr); err != nil {
+	rem, err := remote.New(string(b.Get([]byte(fmt.Sprintf("%s-remote", h.ID)))), token)
+	if err != nil {
 		return err
 	}
-	if pr.PullRequest == nil {
-		return errors.New("not a PR")
+	pr, err := rem.ParsePullRequestEvent(r.Body)
+	if err != nil {
+		return err
 	}
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: string(token)},
-	)
-	tc := oauth2.NewClient(oauth2.NoContext, ts)
-
-	client := github.NewClient(tc)
-
-	owner := *pr.PullRequest.Base.Repo.Owner.Login
-	repo := *pr.PullRequest.Base.Repo.Name
-	number := *pr.Number
-	commits, _, err := client.PullRequests.ListCommits(owner, repo, *pr.Number, &github.ListOptions{})
+	owner, repo, number := pr.Owner, pr.Repo, pr.Number
+	commits, err := rem.ListCommits(ctx, owner, repo, number)
 	if err != nil {
 		return err
 	}
 	var reviewers []string
 
 	for _, c := range commits {
-		x := reviewRegex.FindAllStringSubmatch(*c.Commit.Message, -1)
+		x := reviewRegex.FindAllStringSubmatch(c.Message, -1)
 		for _, match := range x {
 			reviewers = append(reviewers, match[1])
 		}
 	}
-	reviewersReq := &github.PullRequestReviewerRequest{reviewers}
-	_, _, err = client.PullRequests.RequestReviewers(owner, repo, number, reviewersReq)
-	if err != nil {
+	if err := rem.RequestReviewers(ctx, owner, repo, number, reviewers); err != nil {
 		return err
 	}
 	return put(b, DELIVERIES, id, []byte{})
@@ -1,18 +1,17 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net/mail"
 	"regexp"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/boltdb/bolt"
-	"github.com/google/go-github/github"
-	"golang.org/x/oauth2"
+	"github.com/sevki/rehook/remote"
 )
 
 const (
@@ -21,11 +20,11 @@ const (
 )
 
 var (
-	SUCCESS = "success"
-	ERROR   = "error"
-	context = "signed-off-by.me"
-	dco     = "http://signed-off-by.me/"
-	re      = regexp.MustCompile("Signed-off-by: (.* <.*>)")
+	SUCCESS       = "success"
+	ERROR         = "error"
+	statusContext = "signed-off-by.me"
+	dco           = "http://signed-off-by.me/"
+	re            = regexp.MustCompile("Signed-off-by: (.* <.*>)")
 )
 
 func init() {
@@ -47,19 +46,26 @@ func (GithubSignedOffChecker) Template() string { return "github-signed-off-chec
 // from bucket b.
 func (GithubSignedOffChecker) Params(h Hook, b *bolt.Bucket) map[string]string {
 	m := make(map[string]string)
-	for _, k := range []string{"token"} {
-		m[k] = string(b.Get([]byte(fmt.Sprintf("%s-%s", h.ID, k))))
+	for _, k := range []string{"token", "secret"} {
+		v := b.Get([]byte(fmt.Sprintf("%s-%s", h.ID, k)))
+		if v == nil {
+			continue
+		}
+		plain, err := openSecret(h, b, string(v))
+		if err != nil {
+			continue
+		}
+		m[k] = plain
 	}
+	m["remote"] = string(b.Get([]byte(fmt.Sprintf("%s-remote", h.ID))))
 	return m
 }
 
-// Init initializes this component. It requires a token to be present.
+// Init initializes this component; see initSealedParams for the token,
+// secret and remote params it shares with every remote-backed component.
+// The secret is used to verify the HMAC signature of incoming deliveries.
 func (GithubSignedOffChecker) Init(h Hook, params map[string]string, b *bolt.Bucket) error {
-	token, ok := params["token"]
-	if !ok {
-		return errors.New("token is required")
-	}
-	if err := b.Put([]byte(fmt.Sprintf("%s-token", h.ID)), []byte(token)); err != nil {
+	if err := initSealedParams(h, b, params); err != nil {
 		return err
 	}
 	for _, k := range []string{DELIVERIES, SOC} {
@@ -70,38 +76,39 @@ func (GithubSignedOffChecker) Init(h Hook, params map[string]string, b *bolt.Buc
 	return nil
 }
 
-// Process verifies the signature and uniqueness of the delivery identifier.
-func (GithubSignedOffChecker) Process(h Hook, r Request, b *bolt.Bucket) error {
+// Process checks the uniqueness of the delivery identifier; the delivery's
+// signature has already been verified by dispatch before any component
+// runs. ctx is derived from the incoming request and carries the hook's
+// configured timeout; every outbound call made while handling the delivery
+// is bound to it.
+func (GithubSignedOffChecker) Process(ctx context.Context, h Hook, r Request, b *bolt.Bucket) error {
 
-	// Check uniqueness
+	// Check uniqueness, unless this is a replayed delivery being
+	// re-dispatched from the admin interface.
 	id := r.Headers["X-Github-Delivery"]
-	if did := get(b, DELIVERIES, id); did != nil {
+	if did := get(b, DELIVERIES, id); did != nil && !r.Replay {
 		return errors.New("duplicate delivery")
 	}
-	token := b.Get([]byte(fmt.Sprintf("%s-token", h.ID)))
-	if token == nil {
+	sealedToken := b.Get([]byte(fmt.Sprintf("%s-token", h.ID)))
+	if sealedToken == nil {
 		return errors.New("github validator not initialized")
 	}
+	token, err := openSecret(h, b, string(sealedToken))
+	if err != nil {
+		return fmt.Errorf("opening token: %v", err)
+	}
 
-	var pr github.PullRequestEvent
-	if err := json.Unmarshal(r.Body, &pr); err != nil {
+	rem, err := remote.New(string(b.Get([]byte(fmt.Sprintf("%s-remote", h.ID)))), token)
+	if err != nil {
 		return err
 	}
-	if pr.PullRequest == nil {
-		return errors.New("not a PR")
+	pr, err := rem.ParsePullRequestEvent(r.Body)
+	if err != nil {
+		return err
 	}
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: string(token)},
-	)
-	tc := oauth2.NewClient(oauth2.NoContext, ts)
-
-	client := github.NewClient(tc)
-
-	owner := *pr.PullRequest.Base.Repo.Owner.Login
-	repo := *pr.PullRequest.Base.Repo.Name
-	number := *pr.Number
-	commits, _, err := client.PullRequests.ListCommits(owner, repo, *pr.Number, &github.ListOptions{})
+	owner, repo, number := pr.Owner, pr.Repo, pr.Number
+	commits, err := rem.ListCommits(ctx, owner, repo, number)
 	if err != nil {
 		return err
 	}
@@ -109,20 +116,20 @@ func (GithubSignedOffChecker) Process(h Hook, r Request, b *bolt.Bucket) error {
 	var errs []error
 	for _, c := range commits {
 		if err := checkCommit(c); err != nil {
-			unsignedCommits = append(unsignedCommits, *c.SHA)
+			unsignedCommits = append(unsignedCommits, c.SHA)
 			errs = append(errs, err)
 		}
 	}
-	lastCommit := *commits[len(commits)-1].SHA
+	lastCommit := commits[len(commits)-1].SHA
 	if len(unsignedCommits) > 0 {
 
 		var msg string
 		msg = "All commits should be signed-off-by their respective authors"
-		if _, _, err := client.Repositories.CreateStatus(owner, repo, lastCommit, &github.RepoStatus{
-			State:       &ERROR,
-			Context:     &context,
-			TargetURL:   &dco,
-			Description: &msg,
+		if err := rem.CreateStatus(ctx, owner, repo, lastCommit, remote.Status{
+			State:       ERROR,
+			Context:     statusContext,
+			TargetURL:   dco,
+			Description: msg,
 		}); err != nil {
 			return fmt.Errorf("error status send: %v", err)
 		}
@@ -141,66 +148,51 @@ func (GithubSignedOffChecker) Process(h Hook, r Request, b *bolt.Bucket) error {
 		}
 		cmnt += "\n\nIf you'd like more information on how to sign your commits please visit [signed-off-by.me](https://signed-off-by.me)"
 
-		if err := leaveComment(owner, repo, cmnt, number, client, b); err != nil {
+		if err := leaveComment(ctx, owner, repo, cmnt, number, rem, b); err != nil {
 			return err
 		}
 	} else {
 		msg := fmt.Sprintf("All commits are signed-off.")
-		if _, _, err := client.Repositories.CreateStatus(owner, repo, lastCommit, &github.RepoStatus{
-			State:       &SUCCESS,
-			Context:     &context,
-			TargetURL:   &dco,
-			Description: &msg,
+		if err := rem.CreateStatus(ctx, owner, repo, lastCommit, remote.Status{
+			State:       SUCCESS,
+			Context:     statusContext,
+			TargetURL:   dco,
+			Description: msg,
 		}); err != nil {
 			return err
 		}
 		id := pullid(owner, repo, number)
 		if commentID := get(b, SOC, id); commentID != nil {
-			cid, err := strconv.Atoi(string(commentID))
-			if err != nil {
+			if err := rem.DeleteComment(ctx, owner, repo, number, string(commentID)); err != nil {
 				return err
 			}
-			if _, err = client.Issues.DeleteComment(owner, repo, cid); err != nil {
-				return err
-			} else {
-				b.Delete([]byte(id))
-			}
+			b.Delete([]byte(id))
 		}
 
 	}
 
 	return put(b, DELIVERIES, id, []byte{})
 }
-func leaveComment(owner, repo, body string, number int, client *github.Client, b *bolt.Bucket) error {
+func leaveComment(ctx context.Context, owner, repo, body string, number int, rem remote.Remote, b *bolt.Bucket) error {
 	id := pullid(owner, repo, number)
 	commentID := get(b, SOC, id)
 	newComment := func() error {
-		c, _, err := client.Issues.CreateComment(owner, repo, number, &github.IssueComment{Body: &body})
+		cid, err := rem.CreateComment(ctx, owner, repo, number, body)
 		if err != nil {
 			log.Println(err)
 			return err
 		}
-		if err := put(b, SOC, id, []byte(strconv.Itoa(*c.ID))); err != nil {
-			log.Fatal(err)
-			return err
-		}
-		return nil
+		return put(b, SOC, id, []byte(cid))
 	}
 	if commentID != nil {
-		cid, err := strconv.Atoi(string(commentID))
-		if err != nil {
-			return err
-		}
-		_, _, err = client.Issues.EditComment(owner, repo, cid, &github.IssueComment{Body: &body})
+		err := rem.EditComment(ctx, owner, repo, number, string(commentID), body)
 		if err != nil {
 			b.Delete([]byte(id))
 			return newComment()
 		}
 		return err
-	} else {
-		return newComment()
 	}
-	return nil
+	return newComment()
 }
 func pullid(o, r string, n int) string {
 	return fmt.Sprintf("%s-%s-%d", o, r, n)
@@ -216,21 +208,25 @@ func get(b *bolt.Bucket, bname, k string) []byte {
 	}
 	return b.Get([]byte(k))
 }
-func checkCommit(c *github.RepositoryCommit) error {
-	x := re.FindStringSubmatch(*c.Commit.Message)
+func checkCommit(c remote.Commit) error {
+	x := re.FindStringSubmatch(c.Message)
 	if len(x) == 2 {
 		addr, err := mail.ParseAddress(x[1])
 		if err != nil {
-			return fmt.Errorf("%s has a malformed signature.", *c.Commit.Author.Name, (*c.SHA)[:7])
+			return fmt.Errorf("%s has a malformed signature.", c.AuthorName)
 		}
-		if *c.Commit.Author.Name != addr.Name {
+		if c.AuthorName != addr.Name {
 			return fmt.Errorf("Commit author name and signed-off-by author don't match.")
 		}
-		if *c.Commit.Author.Email != addr.Address {
+		if c.AuthorEmail != addr.Address {
 			return fmt.Errorf("Commit author email and signed-off-by address do not match.")
 		}
 		return nil
 	} else {
-		return fmt.Errorf("%s has not signed-off %s.", *c.Commit.Author.Name, (*c.SHA)[:7])
+		return fmt.Errorf("%s has not signed-off %s.", c.AuthorName, c.SHA[:7])
 	}
 }
+
+// defaultProcessTimeout bounds how long a single delivery may block on
+// outbound calls when the hook doesn't configure its own timeout.
+const defaultProcessTimeout = 30 * time.Second
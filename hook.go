@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// hookMetaKey is the key, within a hook's own bucket, that its Hook struct
+// is JSON-encoded under. Component parameters live alongside it in the same
+// bucket, under the "<hookID>-<field>" keys each component manages itself.
+const hookMetaKey = "_meta"
+
+// Hook is a single configured webhook endpoint.
+type Hook struct {
+	ID string `json:"id"`
+	// Components lists the registered component names that run for this
+	// hook, in the order they were added.
+	Components []string `json:"components,omitempty"`
+	// Timeout bounds how long a single delivery may block on outbound
+	// calls made by its components. Zero means defaultProcessTimeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Pipeline is the hook's YAML pipeline definition (see package
+	// pipeline). When set, its steps, filtered by their matchers, decide
+	// which registered components run for a given delivery in place of
+	// Components; a step may name a component that isn't in Components.
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
+// HookStore loads and saves hooks from the hooks bucket. Each hook also owns
+// a nested bucket, keyed by its ID, that components use to store their own
+// parameters.
+type HookStore struct {
+	db *bolt.DB
+}
+
+// Get loads the hook with the given ID.
+func (s *HookStore) Get(id string) (Hook, error) {
+	var h Hook
+	err := s.db.View(func(t *bolt.Tx) error {
+		b := t.Bucket(BucketHooks).Bucket([]byte(id))
+		if b == nil {
+			return fmt.Errorf("hook %q not found", id)
+		}
+		data := b.Get([]byte(hookMetaKey))
+		if data == nil {
+			h.ID = id
+			return nil
+		}
+		return json.Unmarshal(data, &h)
+	})
+	return h, err
+}
+
+// List returns every configured hook.
+func (s *HookStore) List() ([]Hook, error) {
+	var hooks []Hook
+	err := s.db.View(func(t *bolt.Tx) error {
+		return t.Bucket(BucketHooks).ForEach(func(k, v []byte) error {
+			if v != nil {
+				return nil
+			}
+			data := t.Bucket(BucketHooks).Bucket(k).Get([]byte(hookMetaKey))
+			if data == nil {
+				return nil
+			}
+			var h Hook
+			if err := json.Unmarshal(data, &h); err != nil {
+				return err
+			}
+			hooks = append(hooks, h)
+			return nil
+		})
+	})
+	return hooks, err
+}
+
+// Save creates or updates a hook, creating its bucket if necessary.
+func (s *HookStore) Save(h Hook) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(t *bolt.Tx) error {
+		b, err := t.Bucket(BucketHooks).CreateBucketIfNotExists([]byte(h.ID))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(hookMetaKey), data)
+	})
+}
+
+// bucket returns hook id's bucket from within an already-open transaction.
+func (s *HookStore) bucket(t *bolt.Tx, id string) *bolt.Bucket {
+	return t.Bucket(BucketHooks).Bucket([]byte(id))
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/julienschmidt/httprouter"
+	"github.com/sevki/rehook/pipeline"
+)
+
+// HookHandler serves the public endpoint incoming webhooks are delivered
+// to.
+type HookHandler struct {
+	hooks *HookStore
+	db    *bolt.DB
+}
+
+// ReceiveHook verifies an incoming delivery's signature against the secret
+// configured for the hook, rejecting it with 401 before any component runs
+// if verification fails, then dispatches it to the hook's components.
+func (hh *HookHandler) ReceiveHook(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+
+	h, err := hh.hooks.Get(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "could not read request body", http.StatusBadRequest)
+		return
+	}
+	req := Request{Headers: flattenHeader(r.Header), Body: body}
+
+	did := req.Headers["X-Github-Delivery"]
+	if did == "" {
+		did = fmt.Sprintf("%s-%d", id, time.Now().UnixNano())
+	}
+	d := Delivery{ID: did, HookID: id, Headers: req.Headers, Body: body, ReceivedAt: time.Now()}
+	if err := StoreDelivery(hh.db, d); err != nil {
+		log.Printf("hook %s: storing delivery %s: %v", id, did, err)
+	}
+
+	verifyErr := verifySignature(hh.db, hh.hooks, h, req)
+	recordDelivery(id, verifyErr != nil)
+	if verifyErr != nil {
+		d.Status = http.StatusUnauthorized
+		d.Errors = map[string]string{"*": verifyErr.Error()}
+		if err := StoreDelivery(hh.db, d); err != nil {
+			log.Printf("hook %s: storing delivery %s: %v", id, did, err)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, "signature verification failed: %v\n", verifyErr)
+		return
+	}
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = defaultProcessTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	d.Status = http.StatusOK
+	d.Errors = dispatch(ctx, hh.db, hh.hooks, h, req)
+	if err := StoreDelivery(hh.db, d); err != nil {
+		log.Printf("hook %s: storing delivery %s: %v", id, did, err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// verifySignature checks req's signature against the secret configured for
+// hook h, opening the sealed secret from the hook's own bucket. It's the
+// single place delivery authenticity is checked: ReceiveHook calls it
+// directly so it can reject unverified deliveries with 401 before any
+// component runs, and dispatch calls it again so the same guarantee covers
+// deliveries dispatched some other way, such as the admin interface's
+// replay endpoint, rather than depending on every component re-checking it.
+func verifySignature(db *bolt.DB, hooks *HookStore, h Hook, req Request) error {
+	var verifyErr error
+	err := db.View(func(t *bolt.Tx) error {
+		b := hooks.bucket(t, h.ID)
+		if b == nil {
+			verifyErr = errors.New("hook has no bucket")
+			return nil
+		}
+		sealed := b.Get([]byte(fmt.Sprintf("%s-secret", h.ID)))
+		if sealed == nil {
+			verifyErr = errors.New("hook has no secret configured")
+			return nil
+		}
+		secret, err := openSecret(h, b, string(sealed))
+		if err != nil {
+			verifyErr = fmt.Errorf("opening secret: %v", err)
+			return nil
+		}
+		verifyErr = verifyDelivery(req, secret)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return verifyErr
+}
+
+// dispatch runs every component attached to h against req, in order,
+// returning the error (if any) each component returned, keyed by name. A
+// component with no entry succeeded. It stops early if ctx is canceled,
+// e.g. because the client disconnected or the hook's timeout elapsed
+// mid-delivery. It's shared by HookHandler.ReceiveHook and the admin
+// interface's delivery replay endpoint; both rely on it to re-verify the
+// delivery's signature before any component sees it.
+func dispatch(ctx context.Context, db *bolt.DB, hooks *HookStore, h Hook, req Request) map[string]string {
+	defer beginDispatch()()
+	errs := make(map[string]string)
+	if err := verifySignature(db, hooks, h, req); err != nil {
+		errs["*"] = fmt.Sprintf("signature verification failed: %v", err)
+		return errs
+	}
+	registered := Components()
+	steps := selectSteps(h, req)
+	if err := db.Update(func(t *bolt.Tx) error {
+		b := hooks.bucket(t, h.ID)
+		if b == nil {
+			return fmt.Errorf("hook %q has no bucket", h.ID)
+		}
+		for _, step := range steps {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c, ok := registered[step.Component]
+			if !ok {
+				log.Printf("hook %s: component %q is not registered", h.ID, step.Component)
+				continue
+			}
+			stepReq := req
+			stepReq.Args = step.Args
+			start := time.Now()
+			err := c.Process(ctx, h, stepReq, b)
+			recordComponentRun(h.ID, step.Component, time.Since(start), err)
+			if err != nil {
+				log.Printf("hook %s: component %q: %v", h.ID, step.Component, err)
+				errs[step.Component] = err.Error()
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Printf("hook %s: dispatch: %v", h.ID, err)
+		errs["*"] = err.Error()
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// selectSteps returns the pipeline steps that should run for req, each
+// carrying the component to run and its configured arguments. When h has no
+// pipeline configured, every attached component runs for every delivery, as
+// before, with no arguments; otherwise the pipeline's matchers decide which
+// steps apply, and each step's own args are passed through.
+func selectSteps(h Hook, req Request) []pipeline.Step {
+	if h.Pipeline == "" {
+		return legacySteps(h.Components)
+	}
+	cfg, err := pipeline.Parse([]byte(h.Pipeline))
+	if err != nil {
+		log.Printf("hook %s: invalid pipeline, falling back to running every component: %v", h.ID, err)
+		return legacySteps(h.Components)
+	}
+	event, branch, repo := eventContext(req)
+	return cfg.Select(event, branch, repo)
+}
+
+// legacySteps wraps a hook's flat component list as argument-less pipeline
+// steps, for hooks that haven't configured a YAML pipeline.
+func legacySteps(components []string) []pipeline.Step {
+	out := make([]pipeline.Step, len(components))
+	for i, c := range components {
+		out[i] = pipeline.Step{Component: c}
+	}
+	return out
+}
+
+// eventContext derives the event/branch/repo a delivery refers to, for
+// pipeline matcher evaluation. It understands the event-name headers used
+// by GitHub, GitLab, Gitea and Bitbucket, and best-effort sniffs the
+// payload body (which is shaped differently per forge and event type) for a
+// ref and repository name.
+func eventContext(req Request) (event, branch, repo string) {
+	for _, h := range []string{"X-Github-Event", "X-Gitlab-Event", "X-Gitea-Event", "X-Event-Key"} {
+		if v := req.Headers[h]; v != "" {
+			event = v
+			break
+		}
+	}
+	var body struct {
+		Ref        string `json:"ref"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if json.Unmarshal(req.Body, &body) == nil {
+		repo = body.Repository.FullName
+		branch = strings.TrimPrefix(body.Ref, "refs/heads/")
+	}
+	return event, branch, repo
+}
+
+// flattenHeader reduces an http.Header to its first value per key, which is
+// all the signature schemes rehook understands need.
+func flattenHeader(hdr http.Header) map[string]string {
+	out := make(map[string]string, len(hdr))
+	for k, v := range hdr {
+		if len(v) > 0 {
+			out[k] = v[0]
+		}
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestSelectStepsLegacyHasNoArgs(t *testing.T) {
+	h := Hook{Components: []string{"a", "b"}}
+	steps := selectSteps(h, Request{})
+	if len(steps) != 2 {
+		t.Fatalf("selectSteps returned %d steps, want 2", len(steps))
+	}
+	for i, want := range []string{"a", "b"} {
+		if steps[i].Component != want {
+			t.Errorf("steps[%d].Component = %q, want %q", i, steps[i].Component, want)
+		}
+		if steps[i].Args != nil {
+			t.Errorf("steps[%d].Args = %v, want nil for a hook with no pipeline", i, steps[i].Args)
+		}
+	}
+}
+
+func TestSelectStepsYAMLPipelineCarriesArgs(t *testing.T) {
+	h := Hook{Pipeline: `
+steps:
+  - component: github-signed-off-checker
+    args:
+      severity: error
+`}
+	steps := selectSteps(h, Request{})
+	if len(steps) != 1 {
+		t.Fatalf("selectSteps returned %d steps, want 1", len(steps))
+	}
+	if got := steps[0].Args["severity"]; got != "error" {
+		t.Errorf("steps[0].Args[%q] = %q, want %q", "severity", got, "error")
+	}
+}
+
+func TestSelectStepsInvalidPipelineFallsBackWithNoArgs(t *testing.T) {
+	h := Hook{Pipeline: "not: [valid", Components: []string{"a"}}
+	steps := selectSteps(h, Request{})
+	if len(steps) != 1 || steps[0].Component != "a" {
+		t.Fatalf("selectSteps = %+v, want fallback to Components", steps)
+	}
+	if steps[0].Args != nil {
+		t.Errorf("steps[0].Args = %v, want nil on fallback", steps[0].Args)
+	}
+}
@@ -19,9 +19,10 @@ const (
 
 // flags
 var (
-	listenAddr = flag.String("http", ":9000", "Public HTTP listen address for incoming webhooks")
-	adminAddr  = flag.String("admin", ":9001", "Private HTTP listen address for admin interface")
-	database   = flag.String("db", "data.db", "Database file to use")
+	listenAddr        = flag.String("http", ":9000", "Public HTTP listen address for incoming webhooks")
+	adminAddr         = flag.String("admin", ":9001", "Private HTTP listen address for admin interface")
+	database          = flag.String("db", "data.db", "Database file to use")
+	deliveryRetention = flag.Duration("delivery-retention", 30*24*time.Hour, "How long to keep persisted deliveries before pruning them; 0 disables pruning.")
 )
 
 // Database constants
@@ -60,16 +61,41 @@ func main() {
 	hh := &HookHandler{hookStore, db}
 	router := httprouter.New()
 	router.GET("/_status", status)
+	router.GET("/_status.json", statusJSON)
+	router.Handler("GET", "/metrics", metrics)
 	router.GET("/h/:id", hh.ReceiveHook)
 	router.POST("/h/:id", hh.ReceiveHook)
 
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		for range ticker.C {
+			if err := recordBucketSizes(db); err != nil {
+				log.Printf("recordBucketSizes: %s", err)
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		for range ticker.C {
+			n, err := PruneDeliveries(db, *deliveryRetention)
+			if err != nil {
+				log.Printf("PruneDeliveries: %s", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("pruned %d deliveries older than %s", n, *deliveryRetention)
+			}
+		}
+	}()
+
 	go func() {
 		log.Printf("Listening on %s", *listenAddr)
 		log.Print(http.ListenAndServe(*listenAddr, router))
 	}()
 
 	// admin interface
-	ah := &AdminHandler{hookStore}
+	ah := &AdminHandler{hookStore, db}
 	arouter := httprouter.New()
 	arouter.Handler("GET", "/public/*path", http.StripPrefix("/public", http.FileServer(http.Dir("public"))))
 	arouter.GET("/", ah.Index)
@@ -85,12 +111,21 @@ func main() {
 	arouter.GET("/hooks/edit/:id/edit/:c", ah.EditComponent)
 	arouter.POST("/hooks/edit/:id/update/:c", ah.UpdateComponent)
 
+	arouter.GET("/hooks/edit/:id/pipeline", ah.EditPipeline)
+	arouter.POST("/hooks/edit/:id/pipeline", ah.UpdatePipeline)
+
+	arouter.GET("/hooks/:id/deliveries", ah.ListDeliveries)
+	arouter.GET("/deliveries/:did", ah.ShowDelivery)
+	arouter.POST("/deliveries/:did/replay", ah.ReplayDelivery)
+
+	arouter.POST("/keys/rotate", ah.RotateKey)
+
 	log.Printf("Admin interface on %s", *adminAddr)
 	log.Print(http.ListenAndServe(*adminAddr, arouter))
 }
 
 func initBuckets(t *bolt.Tx) error {
-	for _, name := range [][]byte{BucketHooks, BucketStats, BucketComponents} {
+	for _, name := range [][]byte{BucketHooks, BucketStats, BucketComponents, BucketDeliveries} {
 		if _, err := t.CreateBucketIfNotExists(name); err != nil {
 			return err
 		}
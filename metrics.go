@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	deliveriesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "rehook",
+		Name:      "deliveries_received_total",
+		Help:      "Webhook deliveries received, per hook.",
+	}, []string{"hook"})
+
+	deliveriesRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "rehook",
+		Name:      "deliveries_rejected_total",
+		Help:      "Webhook deliveries rejected before dispatch (bad signature, etc), per hook.",
+	}, []string{"hook"})
+
+	componentRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "rehook",
+		Name:      "component_runs_total",
+		Help:      "Component Process invocations, per hook/component/outcome.",
+	}, []string{"hook", "component", "outcome"})
+
+	componentDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "rehook",
+		Name:      "component_process_duration_seconds",
+		Help:      "Time spent in a component's Process method.",
+	}, []string{"hook", "component"})
+
+	bucketSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "rehook",
+		Name:      "bucket_keys",
+		Help:      "Number of keys in a top-level bolt bucket.",
+	}, []string{"bucket"})
+
+	deliveriesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "rehook",
+		Name:      "deliveries_in_flight",
+		Help:      "Deliveries currently being dispatched, across all hooks.",
+	})
+)
+
+// inFlight counts deliveries currently inside dispatch, i.e. past signature
+// verification and either running their components or waiting their turn
+// on bolt's single writer transaction. rehook has no separate delivery
+// queue, so this doubles as the "queue depth" reported by the JSON status
+// endpoint: it counts one per delivery, so a value above one means
+// deliveries are waiting their turn on that writer transaction.
+var inFlight int64
+
+// beginDispatch marks a delivery as in flight and returns a func that marks
+// it done; call it with defer from dispatch.
+func beginDispatch() func() {
+	atomic.AddInt64(&inFlight, 1)
+	deliveriesInFlight.Inc()
+	return func() {
+		atomic.AddInt64(&inFlight, -1)
+		deliveriesInFlight.Dec()
+	}
+}
+
+func queueDepth() int64 {
+	return atomic.LoadInt64(&inFlight)
+}
+
+func init() {
+	// Outbound forge API latency and rate-limit headroom are registered by
+	// the remote package itself (remote/metrics.go), since instrument()
+	// wraps every Remote's http.Client there.
+	prometheus.MustRegister(deliveriesReceived, deliveriesRejected, componentRuns, componentDuration, bucketSize, deliveriesInFlight)
+}
+
+// recordDelivery updates the received/rejected counters for hook h.
+func recordDelivery(hookID string, rejected bool) {
+	deliveriesReceived.WithLabelValues(hookID).Inc()
+	if rejected {
+		deliveriesRejected.WithLabelValues(hookID).Inc()
+	}
+}
+
+// recordComponentRun updates the run counter and duration histogram for a
+// component invocation, and records its outcome for the JSON status
+// endpoint.
+func recordComponentRun(hookID, component string, d time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	componentRuns.WithLabelValues(hookID, component, outcome).Inc()
+	componentDuration.WithLabelValues(hookID, component).Observe(d.Seconds())
+	recordComponentStatus(component, err)
+}
+
+// recordBucketSizes refreshes the bucket_keys gauge for each top-level
+// bucket. It's called periodically rather than per-request, since counting
+// keys requires a full bucket scan.
+func recordBucketSizes(db *bolt.DB) error {
+	return db.View(func(t *bolt.Tx) error {
+		for _, name := range [][]byte{BucketHooks, BucketStats, BucketComponents, BucketDeliveries} {
+			b := t.Bucket(name)
+			if b == nil {
+				continue
+			}
+			n := 0
+			if err := b.ForEach(func(_, _ []byte) error { n++; return nil }); err != nil {
+				return err
+			}
+			bucketSize.WithLabelValues(string(name)).Set(float64(n))
+		}
+		return nil
+	})
+}
+
+// componentStatus is the last observed outcome of a component, kept in
+// memory for the JSON status endpoint.
+type componentStatus struct {
+	LastError   string    `json:"last_error,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastAttempt time.Time `json:"last_attempt"`
+}
+
+var (
+	statusMu sync.Mutex
+	statuses = map[string]*componentStatus{}
+)
+
+func recordComponentStatus(component string, err error) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	s, ok := statuses[component]
+	if !ok {
+		s = &componentStatus{}
+		statuses[component] = s
+	}
+	s.LastAttempt = time.Now()
+	if err != nil {
+		s.LastError = err.Error()
+	} else {
+		s.LastError = ""
+		s.LastSuccess = s.LastAttempt
+	}
+}
+
+// statusReport is returned by the JSON /_status endpoint.
+type statusReport struct {
+	GoVersion  string                      `json:"go_version"`
+	Uptime     string                      `json:"uptime"`
+	QueueDepth int64                       `json:"queue_depth"`
+	Components map[string]*componentStatus `json:"components"`
+}
+
+// statusJSON serves a machine-readable variant of /_status.
+func statusJSON(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	statusMu.Lock()
+	snapshot := make(map[string]*componentStatus, len(statuses))
+	for k, v := range statuses {
+		c := *v
+		snapshot[k] = &c
+	}
+	statusMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statusReport{
+		GoVersion:  runtime.Version(),
+		Uptime:     time.Since(start).String(),
+		QueueDepth: queueDepth(),
+		Components: snapshot,
+	})
+}
+
+// metrics serves Prometheus text-format metrics.
+var metrics = promhttp.Handler()
@@ -0,0 +1,89 @@
+// Package pipeline parses the per-hook YAML pipeline definition that
+// replaces the ad-hoc bolt-stored component/params model: an ordered list
+// of components to run, each with its own arguments and an optional
+// matcher selecting which deliveries it applies to.
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"unicode"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config is the top-level pipeline definition for a hook.
+type Config struct {
+	Steps []Step `yaml:"steps"`
+}
+
+// Step runs a single registered component with the given arguments, for
+// deliveries that satisfy When (or every delivery, if When is nil).
+type Step struct {
+	Component string            `yaml:"component"`
+	Args      map[string]string `yaml:"args"`
+	When      *Matcher          `yaml:"when,omitempty"`
+}
+
+// Matcher selects which deliveries a step runs for. Empty fields match
+// anything.
+type Matcher struct {
+	Event  string `yaml:"event,omitempty"`
+	Branch string `yaml:"branch,omitempty"`
+	Repo   string `yaml:"repo,omitempty"`
+}
+
+// Match reports whether a delivery for the given event, branch and repo
+// satisfies the matcher. Branch is matched as a regular expression.
+func (m *Matcher) Match(event, branch, repo string) bool {
+	if m == nil {
+		return true
+	}
+	if m.Event != "" && m.Event != event {
+		return false
+	}
+	if m.Repo != "" && m.Repo != repo {
+		return false
+	}
+	if m.Branch != "" {
+		re, err := regexp.Compile(m.Branch)
+		if err != nil || !re.MatchString(branch) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse validates and unmarshals a pipeline definition. It rejects non-ASCII
+// whitespace such as U+00A0 (non-breaking space), which YAML accepts as
+// indentation but which silently produces a different document than the
+// one the author intended to write.
+func Parse(data []byte) (*Config, error) {
+	for i, r := range string(data) {
+		if r > unicode.MaxASCII && unicode.IsSpace(r) {
+			return nil, fmt.Errorf("pipeline: non-ASCII whitespace at byte %d", i)
+		}
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	for i, s := range cfg.Steps {
+		if s.Component == "" {
+			return nil, fmt.Errorf("pipeline: step %d has no component", i)
+		}
+	}
+	return &cfg, nil
+}
+
+// Select returns the steps that apply to a delivery for the given event,
+// branch and repo, in configured order.
+func (c *Config) Select(event, branch, repo string) []Step {
+	var out []Step
+	for _, s := range c.Steps {
+		if s.When.Match(event, branch, repo) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	cases := []struct {
+		name                string
+		m                   *Matcher
+		event, branch, repo string
+		want                bool
+	}{
+		{"nil matcher matches anything", nil, "push", "main", "sevki/rehook", true},
+		{"empty matcher matches anything", &Matcher{}, "push", "main", "sevki/rehook", true},
+		{"event mismatch", &Matcher{Event: "pull_request"}, "push", "main", "sevki/rehook", false},
+		{"event match", &Matcher{Event: "push"}, "push", "main", "sevki/rehook", true},
+		{"repo mismatch", &Matcher{Repo: "other/repo"}, "push", "main", "sevki/rehook", false},
+		{"branch regexp match", &Matcher{Branch: "^release-.*"}, "push", "release-1.2", "sevki/rehook", true},
+		{"branch regexp no match", &Matcher{Branch: "^release-.*"}, "push", "main", "sevki/rehook", false},
+		{"invalid branch regexp never matches", &Matcher{Branch: "("}, "push", "main", "sevki/rehook", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.m.Match(c.event, c.branch, c.repo); got != c.want {
+				t.Errorf("Match(%q, %q, %q) = %v, want %v", c.event, c.branch, c.repo, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`
+steps:
+  - component: github-signed-off-checker
+    when:
+      event: push
+  - component: github-review-request
+    args:
+      foo: bar
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(cfg.Steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(cfg.Steps))
+	}
+	if cfg.Steps[0].Component != "github-signed-off-checker" {
+		t.Errorf("step 0 component = %q", cfg.Steps[0].Component)
+	}
+	if cfg.Steps[1].Args["foo"] != "bar" {
+		t.Errorf("step 1 args[foo] = %q, want %q", cfg.Steps[1].Args["foo"], "bar")
+	}
+}
+
+func TestParseRejectsMissingComponent(t *testing.T) {
+	_, err := Parse([]byte(`
+steps:
+  - when:
+      event: push
+`))
+	if err == nil {
+		t.Fatal("Parse: expected error for step with no component, got nil")
+	}
+}
+
+func TestParseRejectsNonASCIIWhitespace(t *testing.T) {
+	// " " is a real UTF-8-encoded non-breaking space (0xC2 0xA0), not
+	// to be confused with the raw byte 0xA0, which isn't valid UTF-8: Parse's
+	// own check doesn't see it as whitespace, so yaml.Unmarshal would be the
+	// one to reject it.
+	_, err := Parse([]byte("steps:\n - component: foo\n"))
+	if err == nil {
+		t.Fatal("Parse: expected error for non-ASCII whitespace, got nil")
+	}
+	if !strings.Contains(err.Error(), "non-ASCII whitespace") {
+		t.Errorf("Parse error = %q, want it to come from the non-ASCII whitespace check", err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	cfg := &Config{Steps: []Step{
+		{Component: "a", When: &Matcher{Event: "push"}},
+		{Component: "b", When: &Matcher{Event: "pull_request"}},
+		{Component: "c"},
+	}}
+	got := cfg.Select("push", "main", "sevki/rehook")
+	if len(got) != 2 {
+		t.Fatalf("Select returned %d steps, want 2: %+v", len(got), got)
+	}
+	if got[0].Component != "a" || got[1].Component != "c" {
+		t.Errorf("Select returned steps in unexpected order: %+v", got)
+	}
+}
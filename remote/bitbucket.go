@@ -0,0 +1,120 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Bitbucket implements Remote against the Bitbucket Cloud 2.0 REST API.
+// Bitbucket Server isn't supported yet.
+type Bitbucket struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewBitbucket returns a Remote authenticated with the given app token.
+func NewBitbucket(token string) *Bitbucket {
+	return &Bitbucket{baseURL: "https://api.bitbucket.org/2.0", token: token, client: instrument(&http.Client{})}
+}
+
+func (bb *Bitbucket) ParsePullRequestEvent(body []byte) (*PullRequest, error) {
+	var ev struct {
+		PullRequest struct {
+			ID          int `json:"id"`
+			Destination struct {
+				Repository struct {
+					FullName string `json:"full_name"`
+				} `json:"repository"`
+			} `json:"destination"`
+		} `json:"pullrequest"`
+	}
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, err
+	}
+	if ev.PullRequest.ID == 0 {
+		return nil, errors.New("remote: not a pull request event")
+	}
+	parts := splitOwnerRepo(ev.PullRequest.Destination.Repository.FullName)
+	return &PullRequest{Owner: parts[0], Repo: parts[1], Number: ev.PullRequest.ID}, nil
+}
+
+func (bb *Bitbucket) ListCommits(ctx context.Context, owner, repo string, number int) ([]Commit, error) {
+	u := fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/commits", bb.baseURL, owner, repo, number)
+	var raw struct {
+		Values []struct {
+			Hash    string `json:"hash"`
+			Message string `json:"message"`
+			Author  struct {
+				Raw string `json:"raw"`
+			} `json:"author"`
+		} `json:"values"`
+	}
+	if err := doJSON(ctx, bb.client, "GET", u, bb.token, nil, &raw); err != nil {
+		return nil, err
+	}
+	out := make([]Commit, len(raw.Values))
+	for i, c := range raw.Values {
+		out[i] = Commit{SHA: c.Hash, Message: c.Message, AuthorName: c.Author.Raw}
+	}
+	return out, nil
+}
+
+func (bb *Bitbucket) CreateStatus(ctx context.Context, owner, repo, sha string, status Status) error {
+	state := status.State
+	switch state {
+	case "success":
+		state = "SUCCESSFUL"
+	case "error", "failure":
+		state = "FAILED"
+	default:
+		state = "INPROGRESS"
+	}
+	u := fmt.Sprintf("%s/repositories/%s/%s/commit/%s/statuses/build", bb.baseURL, owner, repo, sha)
+	return doJSON(ctx, bb.client, "POST", u, bb.token, map[string]string{
+		"state": state, "key": status.Context, "url": status.TargetURL, "description": status.Description,
+	}, nil)
+}
+
+func (bb *Bitbucket) CreateComment(ctx context.Context, owner, repo string, number int, body string) (string, error) {
+	u := fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/comments", bb.baseURL, owner, repo, number)
+	var c struct {
+		ID int `json:"id"`
+	}
+	if err := doJSON(ctx, bb.client, "POST", u, bb.token, map[string]interface{}{"content": map[string]string{"raw": body}}, &c); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(c.ID), nil
+}
+
+func (bb *Bitbucket) EditComment(ctx context.Context, owner, repo string, number int, id string, body string) error {
+	u := fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/comments/%s", bb.baseURL, owner, repo, number, id)
+	return doJSON(ctx, bb.client, "PUT", u, bb.token, map[string]interface{}{"content": map[string]string{"raw": body}}, nil)
+}
+
+func (bb *Bitbucket) DeleteComment(ctx context.Context, owner, repo string, number int, id string) error {
+	u := fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/comments/%s", bb.baseURL, owner, repo, number, id)
+	return doJSON(ctx, bb.client, "DELETE", u, bb.token, nil, nil)
+}
+
+func (bb *Bitbucket) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	return errors.New("remote: bitbucket does not support adding reviewers after pull request creation")
+}
+
+func (bb *Bitbucket) GetCommitVerification(ctx context.Context, owner, repo, sha string) (*CommitVerification, error) {
+	return nil, errors.New("remote: bitbucket does not support commit signature verification")
+}
+
+func (bb *Bitbucket) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	u := fmt.Sprintf("%s/repositories/%s/%s/permissions-config/users/%s", bb.baseURL, owner, repo, user)
+	err := doJSON(ctx, bb.client, "GET", u, bb.token, nil, nil)
+	return err == nil, nil
+}
+
+func (bb *Bitbucket) IsOrgMember(ctx context.Context, org, team, user string) (bool, error) {
+	return false, errors.New("remote: bitbucket workspace/team membership checks are not implemented")
+}
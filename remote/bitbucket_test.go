@@ -0,0 +1,34 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBitbucketEditDeleteCommentIncludePullRequestNumber(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	bb := &Bitbucket{baseURL: srv.URL, client: srv.Client()}
+	const wantPath = "/repositories/sevki/rehook/pullrequests/11/comments/99"
+
+	if err := bb.EditComment(context.Background(), "sevki", "rehook", 11, "99", "updated"); err != nil {
+		t.Fatalf("EditComment: %v", err)
+	}
+	if gotPath != wantPath {
+		t.Errorf("EditComment request path = %q, want %q", gotPath, wantPath)
+	}
+
+	if err := bb.DeleteComment(context.Background(), "sevki", "rehook", 11, "99"); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if gotPath != wantPath {
+		t.Errorf("DeleteComment request path = %q, want %q", gotPath, wantPath)
+	}
+}
@@ -0,0 +1,131 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Gitea implements Remote against the Gitea REST API, which closely mirrors
+// GitHub's own API shape.
+type Gitea struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewGitea returns a Remote authenticated with the given API token, talking
+// to the Gitea instance at gitea.com. The instance URL is not configurable.
+func NewGitea(token string) *Gitea {
+	return &Gitea{baseURL: "https://gitea.com/api/v1", token: token, client: instrument(&http.Client{})}
+}
+
+func (g *Gitea) ParsePullRequestEvent(body []byte) (*PullRequest, error) {
+	var ev struct {
+		Number      int `json:"number"`
+		PullRequest struct {
+			Base struct {
+				Repo struct {
+					Name  string `json:"name"`
+					Owner struct {
+						Login string `json:"login"`
+					} `json:"owner"`
+				} `json:"repo"`
+			} `json:"base"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, err
+	}
+	if ev.Number == 0 {
+		return nil, errors.New("remote: not a pull request event")
+	}
+	return &PullRequest{Owner: ev.PullRequest.Base.Repo.Owner.Login, Repo: ev.PullRequest.Base.Repo.Name, Number: ev.Number}, nil
+}
+
+func (g *Gitea) ListCommits(ctx context.Context, owner, repo string, number int) ([]Commit, error) {
+	u := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/commits", g.baseURL, owner, repo, number)
+	var raw []struct {
+		SHA    string `json:"sha"`
+		Commit struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name  string `json:"name"`
+				Email string `json:"email"`
+			} `json:"author"`
+			Committer struct {
+				Email string `json:"email"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
+	if err := doJSON(ctx, g.client, "GET", u, g.token, nil, &raw); err != nil {
+		return nil, err
+	}
+	out := make([]Commit, len(raw))
+	for i, c := range raw {
+		out[i] = Commit{SHA: c.SHA, Message: c.Commit.Message, AuthorName: c.Commit.Author.Name, AuthorEmail: c.Commit.Author.Email, CommitterEmail: c.Commit.Committer.Email}
+	}
+	return out, nil
+}
+
+func (g *Gitea) CreateStatus(ctx context.Context, owner, repo, sha string, status Status) error {
+	u := fmt.Sprintf("%s/repos/%s/%s/statuses/%s", g.baseURL, owner, repo, sha)
+	return doJSON(ctx, g.client, "POST", u, g.token, map[string]string{
+		"state":       status.State,
+		"context":     status.Context,
+		"target_url":  status.TargetURL,
+		"description": status.Description,
+	}, nil)
+}
+
+func (g *Gitea) CreateComment(ctx context.Context, owner, repo string, number int, body string) (string, error) {
+	u := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", g.baseURL, owner, repo, number)
+	var c struct {
+		ID int `json:"id"`
+	}
+	if err := doJSON(ctx, g.client, "POST", u, g.token, map[string]string{"body": body}, &c); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(c.ID), nil
+}
+
+// EditComment ignores number: Gitea addresses issue/PR comments by id
+// alone.
+func (g *Gitea) EditComment(ctx context.Context, owner, repo string, number int, id string, body string) error {
+	u := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%s", g.baseURL, owner, repo, id)
+	return doJSON(ctx, g.client, "PATCH", u, g.token, map[string]string{"body": body}, nil)
+}
+
+// DeleteComment ignores number: Gitea addresses issue/PR comments by id
+// alone.
+func (g *Gitea) DeleteComment(ctx context.Context, owner, repo string, number int, id string) error {
+	u := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%s", g.baseURL, owner, repo, id)
+	return doJSON(ctx, g.client, "DELETE", u, g.token, nil, nil)
+}
+
+func (g *Gitea) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	u := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/requested_reviewers", g.baseURL, owner, repo, number)
+	return doJSON(ctx, g.client, "POST", u, g.token, map[string][]string{"reviewers": reviewers}, nil)
+}
+
+func (g *Gitea) GetCommitVerification(ctx context.Context, owner, repo, sha string) (*CommitVerification, error) {
+	return nil, errors.New("remote: gitea does not support commit signature verification")
+}
+
+// IsCollaborator checks Gitea's collaborator-check endpoint, which returns
+// 204 if user is a collaborator and 404 otherwise. doJSON surfaces both as
+// an error (since 404 isn't 2xx), so any error here is treated as "not a
+// collaborator" rather than distinguished from a transport failure; that
+// fails closed, which is the right default for a trust check.
+func (g *Gitea) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	u := fmt.Sprintf("%s/repos/%s/%s/collaborators/%s", g.baseURL, owner, repo, user)
+	err := doJSON(ctx, g.client, "GET", u, g.token, nil, nil)
+	return err == nil, nil
+}
+
+func (g *Gitea) IsOrgMember(ctx context.Context, org, team, user string) (bool, error) {
+	return false, errors.New("remote: gitea org/team membership checks are not implemented")
+}
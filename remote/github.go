@@ -0,0 +1,248 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/oauth2"
+)
+
+// Github implements Remote against the github.com (or GitHub Enterprise)
+// API using the official go-github client.
+type Github struct {
+	client *github.Client
+	ts     oauth2.TokenSource
+}
+
+// NewGithub returns a Remote authenticated with the given OAuth token.
+func NewGithub(token string) *Github {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	return &Github{client: github.NewClient(instrument(oauth2.NewClient(oauth2.NoContext, ts))), ts: ts}
+}
+
+// clientFor returns a github.Client bound to ctx, so every outbound request it
+// makes is canceled along with ctx. Calls are instrumented so operators can
+// see API latency and remaining rate-limit headroom.
+func (g *Github) clientFor(ctx context.Context) *github.Client {
+	return github.NewClient(instrument(oauth2.NewClient(ctx, g.ts)))
+}
+
+func (g *Github) ParsePullRequestEvent(body []byte) (*PullRequest, error) {
+	var pr github.PullRequestEvent
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return nil, err
+	}
+	if pr.PullRequest == nil {
+		return nil, errors.New("remote: not a pull request event")
+	}
+	return &PullRequest{
+		Owner:  *pr.PullRequest.Base.Repo.Owner.Login,
+		Repo:   *pr.PullRequest.Base.Repo.Name,
+		Number: *pr.Number,
+	}, nil
+}
+
+func (g *Github) ListCommits(ctx context.Context, owner, repo string, number int) ([]Commit, error) {
+	commits, _, err := g.clientFor(ctx).PullRequests.ListCommits(ctx, owner, repo, number, &github.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Commit, len(commits))
+	for i, c := range commits {
+		out[i] = Commit{
+			SHA:         *c.SHA,
+			Message:     *c.Commit.Message,
+			AuthorName:  *c.Commit.Author.Name,
+			AuthorEmail: *c.Commit.Author.Email,
+		}
+		if c.Commit.Committer != nil && c.Commit.Committer.Email != nil {
+			out[i].CommitterEmail = *c.Commit.Committer.Email
+		}
+		if c.Committer != nil && c.Committer.Login != nil {
+			out[i].CommitterLogin = *c.Committer.Login
+		}
+	}
+	return out, nil
+}
+
+func (g *Github) CreateStatus(ctx context.Context, owner, repo, sha string, status Status) error {
+	_, _, err := g.clientFor(ctx).Repositories.CreateStatus(ctx, owner, repo, sha, &github.RepoStatus{
+		State:       &status.State,
+		Context:     &status.Context,
+		TargetURL:   &status.TargetURL,
+		Description: &status.Description,
+	})
+	return err
+}
+
+func (g *Github) CreateComment(ctx context.Context, owner, repo string, number int, body string) (string, error) {
+	c, _, err := g.clientFor(ctx).Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: &body})
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(*c.ID, 10), nil
+}
+
+// EditComment ignores number: GitHub addresses issue/PR comments by id
+// alone.
+func (g *Github) EditComment(ctx context.Context, owner, repo string, number int, id string, body string) error {
+	cid, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return err
+	}
+	_, _, err = g.clientFor(ctx).Issues.EditComment(ctx, owner, repo, cid, &github.IssueComment{Body: &body})
+	return err
+}
+
+// DeleteComment ignores number: GitHub addresses issue/PR comments by id
+// alone.
+func (g *Github) DeleteComment(ctx context.Context, owner, repo string, number int, id string) error {
+	cid, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return err
+	}
+	_, err = g.clientFor(ctx).Issues.DeleteComment(ctx, owner, repo, cid)
+	return err
+}
+
+func (g *Github) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	_, _, err := g.clientFor(ctx).PullRequests.RequestReviewers(ctx, owner, repo, number, github.ReviewersRequest{Reviewers: reviewers})
+	return err
+}
+
+// GetCommitVerification reports whether sha's signature verifies, and, for
+// a verified signature, who holds the key that produced it. The commit API
+// itself has no "who signed this" field (SignatureVerification only carries
+// Verified/Reason/Signature/Payload), so SignerLogin is resolved separately
+// by extracting the signing key's ID and checking it against the GPG keys
+// of the accounts the commit API does report: its author and committer.
+// That's a real, if narrow, identity check — it can tell a signature
+// actually held by the committer from one that merely claims to be verified
+// — but if neither account's keys match, SignerLogin is left empty rather
+// than guessed.
+func (g *Github) GetCommitVerification(ctx context.Context, owner, repo, sha string) (*CommitVerification, error) {
+	rc, _, err := g.clientFor(ctx).Repositories.GetCommit(ctx, owner, repo, sha)
+	if err != nil {
+		return nil, err
+	}
+	cv := &CommitVerification{}
+	v := rc.Commit.Verification
+	if v == nil {
+		return cv, nil
+	}
+	if v.Verified != nil {
+		cv.Verified = *v.Verified
+	}
+	if v.Reason != nil {
+		cv.Reason = *v.Reason
+	}
+	if cv.Verified && v.Signature != nil {
+		login, err := g.resolveSigner(ctx, *v.Signature, rc)
+		if err != nil {
+			return nil, fmt.Errorf("remote: resolving signer: %v", err)
+		}
+		cv.SignerLogin = login
+	}
+	return cv, nil
+}
+
+// resolveSigner identifies which of a commit's reported accounts (author,
+// committer) holds the GPG key that produced signature, by comparing the
+// signature's issuer key ID against each candidate's public keys via
+// /users/{user}/gpg_keys. Returns "" if no candidate's keys match.
+func (g *Github) resolveSigner(ctx context.Context, signature string, rc *github.RepositoryCommit) (string, error) {
+	keyID, err := signatureKeyID(signature)
+	if err != nil {
+		return "", err
+	}
+	var candidates []string
+	if rc.Author != nil && rc.Author.Login != nil {
+		candidates = append(candidates, *rc.Author.Login)
+	}
+	if rc.Committer != nil && rc.Committer.Login != nil {
+		if len(candidates) == 0 || candidates[0] != *rc.Committer.Login {
+			candidates = append(candidates, *rc.Committer.Login)
+		}
+	}
+	client := g.clientFor(ctx)
+	for _, login := range candidates {
+		keys, _, err := client.Users.ListGPGKeys(ctx, login, nil)
+		if err != nil {
+			return "", err
+		}
+		for _, k := range keys {
+			if gpgKeyHasID(k, keyID) {
+				return login, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// gpgKeyHasID reports whether key or one of its subkeys has issuer key ID
+// id.
+func gpgKeyHasID(key *github.GPGKey, id uint64) bool {
+	if key.KeyID != nil && hexKeyIDEquals(*key.KeyID, id) {
+		return true
+	}
+	for _, sub := range key.Subkeys {
+		if sub.KeyID != nil && hexKeyIDEquals(*sub.KeyID, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func hexKeyIDEquals(hexKeyID string, id uint64) bool {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hexKeyID, "0x"), 16, 64)
+	return err == nil && v == id
+}
+
+// signatureKeyID extracts the issuer key ID from an armored OpenPGP
+// signature, without needing the signer's public key.
+func signatureKeyID(armored string) (uint64, error) {
+	block, err := armor.Decode(strings.NewReader(armored))
+	if err != nil {
+		return 0, err
+	}
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return 0, err
+	}
+	sig, ok := p.(*packet.Signature)
+	if !ok || sig.IssuerKeyId == nil {
+		return 0, errors.New("remote: signature has no issuer key ID")
+	}
+	return *sig.IssuerKeyId, nil
+}
+
+func (g *Github) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	ok, _, err := g.clientFor(ctx).Repositories.IsCollaborator(ctx, owner, repo, user)
+	return ok, err
+}
+
+func (g *Github) IsOrgMember(ctx context.Context, org, team, user string) (bool, error) {
+	client := g.clientFor(ctx)
+	if team == "" {
+		ok, _, err := client.Organizations.IsMember(ctx, org, user)
+		return ok, err
+	}
+	teams, _, err := client.Teams.ListTeams(ctx, org, &github.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, t := range teams {
+		if t.Name != nil && *t.Name == team {
+			ok, _, err := client.Teams.IsTeamMember(ctx, *t.ID, user)
+			return ok, err
+		}
+	}
+	return false, fmt.Errorf("remote: team %q not found in org %q", team, org)
+}
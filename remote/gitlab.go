@@ -0,0 +1,135 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Gitlab implements Remote against the GitLab REST API for merge requests.
+type Gitlab struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewGitlab returns a Remote authenticated with the given personal access
+// token, talking to gitlab.com. Self-managed instances aren't supported yet.
+func NewGitlab(token string) *Gitlab {
+	return &Gitlab{baseURL: "https://gitlab.com/api/v4", token: token, client: instrument(&http.Client{})}
+}
+
+func (g *Gitlab) project(owner, repo string) string {
+	return url.PathEscape(owner + "/" + repo)
+}
+
+func (g *Gitlab) ParsePullRequestEvent(body []byte) (*PullRequest, error) {
+	var ev struct {
+		ObjectAttributes struct {
+			IID    int `json:"iid"`
+			Target struct {
+				PathWithNamespace string `json:"path_with_namespace"`
+			} `json:"target"`
+		} `json:"object_attributes"`
+	}
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, err
+	}
+	if ev.ObjectAttributes.IID == 0 {
+		return nil, errors.New("remote: not a merge request event")
+	}
+	parts := splitOwnerRepo(ev.ObjectAttributes.Target.PathWithNamespace)
+	return &PullRequest{Owner: parts[0], Repo: parts[1], Number: ev.ObjectAttributes.IID}, nil
+}
+
+func (g *Gitlab) ListCommits(ctx context.Context, owner, repo string, number int) ([]Commit, error) {
+	u := fmt.Sprintf("%s/projects/%s/merge_requests/%d/commits", g.baseURL, g.project(owner, repo), number)
+	var raw []struct {
+		ID             string `json:"id"`
+		Message        string `json:"message"`
+		AuthorName     string `json:"author_name"`
+		AuthorEmail    string `json:"author_email"`
+		CommitterEmail string `json:"committer_email"`
+	}
+	if err := doJSON(ctx, g.client, "GET", u, g.token, nil, &raw); err != nil {
+		return nil, err
+	}
+	out := make([]Commit, len(raw))
+	for i, c := range raw {
+		out[i] = Commit{SHA: c.ID, Message: c.Message, AuthorName: c.AuthorName, AuthorEmail: c.AuthorEmail, CommitterEmail: c.CommitterEmail}
+	}
+	return out, nil
+}
+
+func (g *Gitlab) CreateStatus(ctx context.Context, owner, repo, sha string, status Status) error {
+	state := status.State
+	if state == "error" {
+		state = "failed"
+	}
+	u := fmt.Sprintf("%s/projects/%s/statuses/%s?state=%s&name=%s&target_url=%s&description=%s",
+		g.baseURL, g.project(owner, repo), sha,
+		url.QueryEscape(state), url.QueryEscape(status.Context), url.QueryEscape(status.TargetURL), url.QueryEscape(status.Description))
+	return doJSON(ctx, g.client, "POST", u, g.token, nil, nil)
+}
+
+func (g *Gitlab) CreateComment(ctx context.Context, owner, repo string, number int, body string) (string, error) {
+	u := fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", g.baseURL, g.project(owner, repo), number)
+	var note struct {
+		ID int `json:"id"`
+	}
+	if err := doJSON(ctx, g.client, "POST", u, g.token, map[string]string{"body": body}, &note); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(note.ID), nil
+}
+
+func (g *Gitlab) EditComment(ctx context.Context, owner, repo string, number int, id string, body string) error {
+	u := fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes/%s", g.baseURL, g.project(owner, repo), number, id)
+	return doJSON(ctx, g.client, "PUT", u, g.token, map[string]string{"body": body}, nil)
+}
+
+func (g *Gitlab) DeleteComment(ctx context.Context, owner, repo string, number int, id string) error {
+	u := fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes/%s", g.baseURL, g.project(owner, repo), number, id)
+	return doJSON(ctx, g.client, "DELETE", u, g.token, nil, nil)
+}
+
+func (g *Gitlab) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	u := fmt.Sprintf("%s/projects/%s/merge_requests/%d?reviewer_usernames=%s", g.baseURL, g.project(owner, repo), number, url.QueryEscape(joinComma(reviewers)))
+	return doJSON(ctx, g.client, "PUT", u, g.token, nil, nil)
+}
+
+func (g *Gitlab) GetCommitVerification(ctx context.Context, owner, repo, sha string) (*CommitVerification, error) {
+	return nil, errors.New("remote: gitlab does not support commit signature verification")
+}
+
+func (g *Gitlab) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	return false, errors.New("remote: gitlab collaborator checks are not implemented")
+}
+
+func (g *Gitlab) IsOrgMember(ctx context.Context, org, team, user string) (bool, error) {
+	return false, errors.New("remote: gitlab group membership checks are not implemented")
+}
+
+func splitOwnerRepo(pathWithNamespace string) [2]string {
+	for i := len(pathWithNamespace) - 1; i >= 0; i-- {
+		if pathWithNamespace[i] == '/' {
+			return [2]string{pathWithNamespace[:i], pathWithNamespace[i+1:]}
+		}
+	}
+	return [2]string{"", pathWithNamespace}
+}
+
+func joinComma(ss []string) string {
+	out := ""
+	for i, s := range ss {
+		if i > 0 {
+			out += ","
+		}
+		out += s
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitlabEditDeleteCommentIncludeMergeRequestNumber(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	g := &Gitlab{baseURL: srv.URL, client: srv.Client()}
+	const wantPath = "/projects/sevki%2Frehook/merge_requests/7/notes/42"
+
+	if err := g.EditComment(context.Background(), "sevki", "rehook", 7, "42", "updated"); err != nil {
+		t.Fatalf("EditComment: %v", err)
+	}
+	if gotPath != wantPath {
+		t.Errorf("EditComment request path = %q, want %q", gotPath, wantPath)
+	}
+
+	if err := g.DeleteComment(context.Background(), "sevki", "rehook", 7, "42"); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if gotPath != wantPath {
+		t.Errorf("DeleteComment request path = %q, want %q", gotPath, wantPath)
+	}
+}
@@ -0,0 +1,66 @@
+package remote
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	apiDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "rehook",
+		Subsystem: "remote",
+		Name:      "api_duration_seconds",
+		Help:      "Latency of outbound calls to a forge's API, by host and outcome.",
+	}, []string{"host", "outcome"})
+
+	rateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "rehook",
+		Subsystem: "remote",
+		Name:      "rate_limit_remaining",
+		Help:      "Remaining API calls before the forge's rate limit resets, by host.",
+	}, []string{"host"})
+)
+
+func init() {
+	prometheus.MustRegister(apiDuration, rateLimitRemaining)
+}
+
+// instrumentedTransport wraps an http.RoundTripper to record call latency
+// and, where the forge reports it, remaining rate-limit headroom.
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	} else if resp.StatusCode >= 400 {
+		outcome = "http_error"
+	}
+	apiDuration.WithLabelValues(req.URL.Host, outcome).Observe(time.Since(start).Seconds())
+	if resp != nil {
+		if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+			if n, parseErr := strconv.ParseFloat(remaining, 64); parseErr == nil {
+				rateLimitRemaining.WithLabelValues(req.URL.Host).Set(n)
+			}
+		}
+	}
+	return resp, err
+}
+
+// instrument wraps client's Transport (defaulting to http.DefaultTransport)
+// with instrumentedTransport.
+func instrument(client *http.Client) *http.Client {
+	rt := client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	client.Transport = &instrumentedTransport{next: rt}
+	return client
+}
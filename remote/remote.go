@@ -0,0 +1,103 @@
+// Package remote abstracts the forge-specific APIs that rehook components
+// need, so a component can be authored once and run against GitHub, GitLab,
+// Gitea or Bitbucket depending on how the hook it's attached to is
+// configured.
+package remote
+
+import (
+	"context"
+	"fmt"
+)
+
+// Commit is a single commit on a pull/merge request, normalized across
+// forges.
+type Commit struct {
+	SHA            string
+	Message        string
+	AuthorName     string
+	AuthorEmail    string
+	CommitterEmail string
+	// CommitterLogin is the forge account that committed this commit
+	// (distinct from the git commit's free-text author/committer name and
+	// email), when the forge reports one.
+	CommitterLogin string
+}
+
+// Status is a combined/commit status to report back to the forge.
+type Status struct {
+	State       string // "success", "error", "pending" or "failure"
+	Context     string
+	TargetURL   string
+	Description string
+}
+
+// PullRequest identifies the repository and number a delivery refers to.
+type PullRequest struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// CommitVerification describes whether a forge considers a commit's
+// signature verified, and who it attributes the signature to.
+type CommitVerification struct {
+	Verified bool
+	// Reason is the forge's own explanation for the verification
+	// outcome (GitHub's commit.verification.reason, for example).
+	Reason string
+	// SignerLogin is the forge account whose GPG key produced the
+	// signature, when it can be determined. Forges don't generally
+	// report this directly; it may require checking the signature
+	// against candidate accounts' public keys, and is left empty if no
+	// candidate's keys match.
+	SignerLogin string
+}
+
+// Remote is implemented by each supported forge. Components depend only on
+// this interface, never on a concrete forge client. Every outbound call
+// takes a context.Context so callers can bound how long a delivery is
+// allowed to block on it.
+type Remote interface {
+	// ParsePullRequestEvent extracts the repository/number a webhook
+	// delivery body refers to. It returns an error if body isn't a pull
+	// request (or merge request) event.
+	ParsePullRequestEvent(body []byte) (*PullRequest, error)
+
+	ListCommits(ctx context.Context, owner, repo string, number int) ([]Commit, error)
+	CreateStatus(ctx context.Context, owner, repo, sha string, status Status) error
+	CreateComment(ctx context.Context, owner, repo string, number int, body string) (id string, err error)
+	// EditComment and DeleteComment take the pull/merge request number a
+	// comment belongs to in addition to its own id: GitHub and Gitea
+	// address comments by id alone, but GitLab and Bitbucket require the
+	// merge/pull request number in the URL too.
+	EditComment(ctx context.Context, owner, repo string, number int, id string, body string) error
+	DeleteComment(ctx context.Context, owner, repo string, number int, id string) error
+	RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error
+
+	// GetCommitVerification reports whether sha's signature verifies,
+	// and who it's attributed to. Forges without a signature
+	// verification API return an error.
+	GetCommitVerification(ctx context.Context, owner, repo, sha string) (*CommitVerification, error)
+	// IsCollaborator reports whether user is a collaborator on repo.
+	IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error)
+	// IsOrgMember reports whether user is a member of org, or of team
+	// within org when team is non-empty.
+	IsOrgMember(ctx context.Context, org, team, user string) (bool, error)
+}
+
+// New constructs the Remote implementation named by kind, authenticating
+// with token. kind is one of "github", "gitlab", "gitea" or "bitbucket".
+func New(kind, token string) (Remote, error) {
+	switch kind {
+	case "", "github":
+		return NewGithub(token), nil
+	case "gitlab":
+		return NewGitlab(token), nil
+	case "gitea":
+		return NewGitea(token), nil
+	case "bitbucket":
+		return NewBitbucket(token), nil
+	default:
+		return nil, fmt.Errorf("remote: unknown kind %q", kind)
+	}
+}
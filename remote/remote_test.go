@@ -0,0 +1,134 @@
+package remote
+
+import "testing"
+
+func TestSplitOwnerRepo(t *testing.T) {
+	tests := []struct {
+		in        string
+		wantOwner string
+		wantRepo  string
+	}{
+		{"sevki/rehook", "sevki", "rehook"},
+		{"group/subgroup/project", "group/subgroup", "project"},
+		{"no-slash", "", "no-slash"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		got := splitOwnerRepo(tt.in)
+		if got[0] != tt.wantOwner || got[1] != tt.wantRepo {
+			t.Errorf("splitOwnerRepo(%q) = %q, %q, want %q, %q", tt.in, got[0], got[1], tt.wantOwner, tt.wantRepo)
+		}
+	}
+}
+
+func TestJoinComma(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"alice"}, "alice"},
+		{[]string{"alice", "bob", "carol"}, "alice,bob,carol"},
+	}
+	for _, tt := range tests {
+		if got := joinComma(tt.in); got != tt.want {
+			t.Errorf("joinComma(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGithubParsePullRequestEvent(t *testing.T) {
+	g := NewGithub("token")
+	body := []byte(`{
+		"number": 42,
+		"pull_request": {
+			"base": {
+				"repo": {"name": "rehook", "owner": {"login": "sevki"}}
+			}
+		}
+	}`)
+	pr, err := g.ParsePullRequestEvent(body)
+	if err != nil {
+		t.Fatalf("ParsePullRequestEvent: %v", err)
+	}
+	if pr.Owner != "sevki" || pr.Repo != "rehook" || pr.Number != 42 {
+		t.Errorf("ParsePullRequestEvent = %+v, want {sevki rehook 42}", pr)
+	}
+
+	if _, err := g.ParsePullRequestEvent([]byte(`{"action": "opened"}`)); err == nil {
+		t.Error("ParsePullRequestEvent: expected error for a non-pull-request event")
+	}
+}
+
+func TestGitlabParsePullRequestEvent(t *testing.T) {
+	g := NewGitlab("token")
+	body := []byte(`{
+		"object_attributes": {
+			"iid": 7,
+			"target": {"path_with_namespace": "sevki/rehook"}
+		}
+	}`)
+	pr, err := g.ParsePullRequestEvent(body)
+	if err != nil {
+		t.Fatalf("ParsePullRequestEvent: %v", err)
+	}
+	if pr.Owner != "sevki" || pr.Repo != "rehook" || pr.Number != 7 {
+		t.Errorf("ParsePullRequestEvent = %+v, want {sevki rehook 7}", pr)
+	}
+
+	if _, err := g.ParsePullRequestEvent([]byte(`{}`)); err == nil {
+		t.Error("ParsePullRequestEvent: expected error for a non-merge-request event")
+	}
+}
+
+func TestGiteaParsePullRequestEvent(t *testing.T) {
+	g := NewGitea("token")
+	body := []byte(`{
+		"number": 3,
+		"pull_request": {
+			"base": {
+				"repo": {"name": "rehook", "owner": {"login": "sevki"}}
+			}
+		}
+	}`)
+	pr, err := g.ParsePullRequestEvent(body)
+	if err != nil {
+		t.Fatalf("ParsePullRequestEvent: %v", err)
+	}
+	if pr.Owner != "sevki" || pr.Repo != "rehook" || pr.Number != 3 {
+		t.Errorf("ParsePullRequestEvent = %+v, want {sevki rehook 3}", pr)
+	}
+
+	if _, err := g.ParsePullRequestEvent([]byte(`{}`)); err == nil {
+		t.Error("ParsePullRequestEvent: expected error for a non-pull-request event")
+	}
+}
+
+func TestBitbucketParsePullRequestEvent(t *testing.T) {
+	bb := NewBitbucket("token")
+	body := []byte(`{
+		"pullrequest": {
+			"id": 11,
+			"destination": {
+				"repository": {"full_name": "sevki/rehook"}
+			}
+		}
+	}`)
+	pr, err := bb.ParsePullRequestEvent(body)
+	if err != nil {
+		t.Fatalf("ParsePullRequestEvent: %v", err)
+	}
+	if pr.Owner != "sevki" || pr.Repo != "rehook" || pr.Number != 11 {
+		t.Errorf("ParsePullRequestEvent = %+v, want {sevki rehook 11}", pr)
+	}
+
+	if _, err := bb.ParsePullRequestEvent([]byte(`{}`)); err == nil {
+		t.Error("ParsePullRequestEvent: expected error for a non-pull-request event")
+	}
+}
+
+func TestNewUnknownKind(t *testing.T) {
+	if _, err := New("unknown-forge", "token"); err == nil {
+		t.Error("New: expected error for an unknown remote kind")
+	}
+}
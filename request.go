@@ -0,0 +1,18 @@
+package main
+
+// Request is the normalized representation of an incoming webhook delivery
+// passed to each component's Process method.
+type Request struct {
+	Headers map[string]string
+	Body    []byte
+	// Replay is set when this delivery is being re-dispatched from the
+	// admin interface rather than received fresh, so components can skip
+	// their own delivery-uniqueness checks.
+	Replay bool
+	// Args holds the arguments configured for the current step in the
+	// hook's YAML pipeline (pipeline.Step.Args), if any. It's set fresh
+	// by dispatch before every component's Process call, so a component
+	// attached as multiple steps sees each step's own arguments. It's
+	// nil for hooks with no pipeline configured.
+	Args map[string]string
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/boltdb/bolt"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const masterKeyEnv = "REHOOK_MASTER_KEY"
+
+// activeKey holds the master key currently in effect, once resolved. It
+// starts nil and is populated from the environment on first use; a
+// successful RotateMasterKey overwrites it directly so the running process
+// keeps using the key it actually re-sealed everything under, rather than
+// re-reading REHOOK_MASTER_KEY (which an operator may not have updated yet,
+// or which a restart-free rotation never touches at all).
+var (
+	activeKeyMu sync.RWMutex
+	activeKey   []byte
+)
+
+// masterKey returns the server's active master key. It's used only to wrap
+// per-hook private keys, never to encrypt secrets directly, so rotating it
+// doesn't require re-encrypting every secret in the database. The env value
+// is hashed down to a fixed 32-byte A256GCM key rather than used verbatim,
+// so operators aren't required to provision a key of exactly the right
+// length.
+func masterKey() ([]byte, error) {
+	activeKeyMu.RLock()
+	k := activeKey
+	activeKeyMu.RUnlock()
+	if k != nil {
+		return k, nil
+	}
+	env := os.Getenv(masterKeyEnv)
+	if env == "" {
+		return nil, fmt.Errorf("%s is not set", masterKeyEnv)
+	}
+	nk := normalizeKey(env)
+	activeKeyMu.Lock()
+	activeKey = nk
+	activeKeyMu.Unlock()
+	return nk, nil
+}
+
+// normalizeKey derives a 32-byte A256GCM key from an operator-supplied
+// value of arbitrary length.
+func normalizeKey(k string) []byte {
+	sum := sha256.Sum256([]byte(k))
+	return sum[:]
+}
+
+// generateHookKey creates a new RSA keypair for hook h, seals the private
+// key under the server's master key, and stores both halves in the hook's
+// bucket b.
+func generateHookKey(h Hook, b *bolt.Bucket) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	priv := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+	mk, err := masterKey()
+	if err != nil {
+		return err
+	}
+	sealedPriv, err := encryptWithKey(mk, priv)
+	if err != nil {
+		return err
+	}
+	if err := b.Put([]byte(fmt.Sprintf("%s-privkey", h.ID)), []byte(sealedPriv)); err != nil {
+		return err
+	}
+	return b.Put([]byte(fmt.Sprintf("%s-pubkey", h.ID)), pub)
+}
+
+func hookPublicKey(h Hook, b *bolt.Bucket) (*rsa.PublicKey, error) {
+	der := b.Get([]byte(fmt.Sprintf("%s-pubkey", h.ID)))
+	if der == nil {
+		return nil, fmt.Errorf("hook %s has no key configured", h.ID)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("hook key is not an RSA key")
+	}
+	return rsaPub, nil
+}
+
+func hookPrivateKey(h Hook, b *bolt.Bucket) (*rsa.PrivateKey, error) {
+	sealed := b.Get([]byte(fmt.Sprintf("%s-privkey", h.ID)))
+	if sealed == nil {
+		return nil, fmt.Errorf("hook %s has no key configured", h.ID)
+	}
+	mk, err := masterKey()
+	if err != nil {
+		return nil, err
+	}
+	priv, err := decryptWithKey(mk, string(sealed))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(priv)
+	if block == nil {
+		return nil, errors.New("hook private key is corrupt")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// initSealedParams does the token/secret bookkeeping shared by every
+// component that authenticates against a remote forge: it requires a token
+// and secret to be present, generates the hook's keypair on first use,
+// seals both values under it, and stores them alongside the remote kind in
+// b. Components with additional params of their own store those separately
+// in their own Init.
+func initSealedParams(h Hook, b *bolt.Bucket, params map[string]string) error {
+	token, ok := params["token"]
+	if !ok {
+		return errors.New("token is required")
+	}
+	secret, ok := params["secret"]
+	if !ok {
+		return errors.New("secret is required")
+	}
+	if b.Get([]byte(fmt.Sprintf("%s-pubkey", h.ID))) == nil {
+		if err := generateHookKey(h, b); err != nil {
+			return err
+		}
+	}
+	sealedToken, err := sealSecret(h, b, token)
+	if err != nil {
+		return err
+	}
+	sealedSecret, err := sealSecret(h, b, secret)
+	if err != nil {
+		return err
+	}
+	for _, kv := range []struct{ k, v string }{
+		{"token", sealedToken}, {"secret", sealedSecret}, {"remote", params["remote"]},
+	} {
+		if err := b.Put([]byte(fmt.Sprintf("%s-%s", h.ID, kv.k)), []byte(kv.v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sealSecret encrypts plaintext under hook h's public key, returning a
+// compact JWE. initSealedParams uses it for a component's token and secret
+// params; no other component params are sealed.
+func sealSecret(h Hook, b *bolt.Bucket, plaintext string) (string, error) {
+	pub, err := hookPublicKey(h, b)
+	if err != nil {
+		return "", err
+	}
+	enc, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: jose.RSA_OAEP, Key: pub}, nil)
+	if err != nil {
+		return "", err
+	}
+	obj, err := enc.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return obj.CompactSerialize()
+}
+
+// openSecret decrypts a value previously produced by sealSecret.
+func openSecret(h Hook, b *bolt.Bucket, ciphertext string) (string, error) {
+	priv, err := hookPrivateKey(h, b)
+	if err != nil {
+		return "", err
+	}
+	obj, err := jose.ParseEncrypted(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := obj.Decrypt(priv)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// encryptWithKey/decryptWithKey wrap a value with a symmetric key (the
+// server's master key), used only to seal per-hook private keys at rest.
+func encryptWithKey(key, plaintext []byte) (string, error) {
+	enc, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: jose.DIRECT, Key: key}, nil)
+	if err != nil {
+		return "", err
+	}
+	obj, err := enc.Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return obj.CompactSerialize()
+}
+
+func decryptWithKey(key []byte, ciphertext string) ([]byte, error) {
+	obj, err := jose.ParseEncrypted(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return obj.Decrypt(key)
+}
+
+// RotateMasterKey re-encrypts every hook's sealed private key under newKey,
+// inside a single bolt transaction so a crash partway through can't leave
+// some keys sealed under the old key and others under the new one. It walks
+// every bucket reachable from BucketHooks looking for "<hookID>-privkey"
+// entries, since each hook's keypair lives in that hook's own bucket. newKey
+// is the operator-supplied value (e.g. the new REHOOK_MASTER_KEY), not yet
+// normalized.
+//
+// Once the re-seal commits, RotateMasterKey makes nk the active key for the
+// rest of this process's lifetime: the operator still needs to update
+// REHOOK_MASTER_KEY before the next restart, but nothing in between has to
+// fail waiting for that, and no in-flight delivery is decrypted with a key
+// that no longer matches what's on disk.
+func RotateMasterKey(db *bolt.DB, newKey string) error {
+	oldKey, err := masterKey()
+	if err != nil {
+		return err
+	}
+	nk := normalizeKey(newKey)
+	if err := db.Update(func(t *bolt.Tx) error {
+		return rotateBucket(t.Bucket(BucketHooks), oldKey, nk)
+	}); err != nil {
+		return err
+	}
+	activeKeyMu.Lock()
+	activeKey = nk
+	activeKeyMu.Unlock()
+	return nil
+}
+
+func rotateBucket(b *bolt.Bucket, oldKey, newKey []byte) error {
+	if b == nil {
+		return nil
+	}
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			if err := rotateBucket(b.Bucket(k), oldKey, newKey); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasSuffix(string(k), "-privkey") {
+			continue
+		}
+		priv, err := decryptWithKey(oldKey, string(v))
+		if err != nil {
+			return err
+		}
+		sealed, err := encryptWithKey(newKey, priv)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(k, []byte(sealed)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
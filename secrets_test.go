@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+// resetActiveKey clears the in-process cached master key so a test can
+// control whether masterKey() re-reads the environment.
+func resetActiveKey(t *testing.T) {
+	t.Helper()
+	activeKeyMu.Lock()
+	activeKey = nil
+	activeKeyMu.Unlock()
+}
+
+func withHookBucket(t *testing.T, fn func(b *bolt.Bucket)) {
+	t.Helper()
+	resetActiveKey(t)
+	os.Setenv(masterKeyEnv, "a-test-master-key-of-arbitrary-length")
+	defer os.Unsetenv(masterKeyEnv)
+	defer resetActiveKey(t)
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "rehook.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("bolt.Open: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("hook-under-test"))
+		if err != nil {
+			return err
+		}
+		fn(b)
+		return nil
+	}); err != nil {
+		t.Fatalf("db.Update: %v", err)
+	}
+}
+
+func TestSealOpenSecretRoundTrip(t *testing.T) {
+	h := Hook{ID: "hook-under-test"}
+	withHookBucket(t, func(b *bolt.Bucket) {
+		if err := generateHookKey(h, b); err != nil {
+			t.Fatalf("generateHookKey: %v", err)
+		}
+		sealed, err := sealSecret(h, b, "top secret token")
+		if err != nil {
+			t.Fatalf("sealSecret: %v", err)
+		}
+		if sealed == "top secret token" {
+			t.Fatal("sealSecret returned plaintext unchanged")
+		}
+		plain, err := openSecret(h, b, sealed)
+		if err != nil {
+			t.Fatalf("openSecret: %v", err)
+		}
+		if plain != "top secret token" {
+			t.Errorf("openSecret = %q, want %q", plain, "top secret token")
+		}
+	})
+}
+
+func TestNormalizeKeyIsFixedLength(t *testing.T) {
+	for _, k := range []string{"short", "", "a fairly long operator-chosen passphrase that isn't 32 bytes"} {
+		if got := len(normalizeKey(k)); got != 32 {
+			t.Errorf("normalizeKey(%q) has length %d, want 32", k, got)
+		}
+	}
+}
+
+func TestMasterKeyMissing(t *testing.T) {
+	resetActiveKey(t)
+	defer resetActiveKey(t)
+	os.Unsetenv(masterKeyEnv)
+	if _, err := masterKey(); err == nil {
+		t.Error("masterKey: expected error when REHOOK_MASTER_KEY is unset")
+	}
+}
+
+// TestRotateMasterKeyUpdatesActiveKey confirms a rotation takes effect for
+// the rest of this process immediately: a secret sealed before rotation
+// must still open afterwards, using only the in-memory key, without
+// REHOOK_MASTER_KEY ever being updated to match.
+func TestRotateMasterKeyUpdatesActiveKey(t *testing.T) {
+	resetActiveKey(t)
+	defer resetActiveKey(t)
+	os.Setenv(masterKeyEnv, "old-master-key")
+	defer os.Unsetenv(masterKeyEnv)
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "rehook.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("bolt.Open: %v", err)
+	}
+	defer db.Close()
+
+	h := Hook{ID: "hook-under-test"}
+	var sealed string
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(BucketHooks)
+		if err != nil {
+			return err
+		}
+		hb, err := b.CreateBucketIfNotExists([]byte(h.ID))
+		if err != nil {
+			return err
+		}
+		if err := generateHookKey(h, hb); err != nil {
+			return err
+		}
+		sealed, err = sealSecret(h, hb, "top secret token")
+		return err
+	}); err != nil {
+		t.Fatalf("db.Update: %v", err)
+	}
+
+	if err := RotateMasterKey(db, "new-master-key"); err != nil {
+		t.Fatalf("RotateMasterKey: %v", err)
+	}
+
+	// Deliberately leave REHOOK_MASTER_KEY set to the old value: the
+	// rotated key must already be active in-process.
+	if err := db.View(func(tx *bolt.Tx) error {
+		hb := tx.Bucket(BucketHooks).Bucket([]byte(h.ID))
+		plain, err := openSecret(h, hb, sealed)
+		if err != nil {
+			return err
+		}
+		if plain != "top secret token" {
+			t.Errorf("openSecret after rotation = %q, want %q", plain, "top secret token")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("openSecret after rotation: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strings"
+)
+
+// verifyDelivery checks the authenticity of an incoming webhook delivery
+// against the secret configured for the hook. It understands the signature
+// schemes used by GitHub (X-Hub-Signature / X-Hub-Signature-256), GitLab
+// (X-Gitlab-Token) and Bitbucket (UUID header), and returns an error if the
+// delivery cannot be verified.
+func verifyDelivery(r Request, secret string) error {
+	if secret == "" {
+		return errors.New("hook has no secret configured")
+	}
+	switch {
+	case r.Headers["X-Hub-Signature-256"] != "":
+		return verifyHMAC(sha256.New, r.Headers["X-Hub-Signature-256"], "sha256=", secret, r.Body)
+	case r.Headers["X-Hub-Signature"] != "":
+		return verifyHMAC(sha1.New, r.Headers["X-Hub-Signature"], "sha1=", secret, r.Body)
+	case r.Headers["X-Gitlab-Token"] != "":
+		if subtle.ConstantTimeCompare([]byte(r.Headers["X-Gitlab-Token"]), []byte(secret)) != 1 {
+			return errors.New("gitlab token mismatch")
+		}
+		return nil
+	// Header keys are canonicalized by flattenHeader, so Bitbucket's
+	// X-Hook-UUID header arrives as X-Hook-Uuid.
+	case r.Headers["X-Hook-Uuid"] != "":
+		if subtle.ConstantTimeCompare([]byte(r.Headers["X-Hook-Uuid"]), []byte(secret)) != 1 {
+			return errors.New("bitbucket hook uuid mismatch")
+		}
+		return nil
+	default:
+		return errors.New("no recognized signature header present")
+	}
+}
+
+// verifyHMAC recomputes the HMAC of body using the given hash constructor and
+// secret, and compares it in constant time against sig, which is expected to
+// be prefixed with prefix (e.g. "sha256=").
+func verifyHMAC(newHash func() hash.Hash, sig, prefix, secret string, body []byte) error {
+	if !strings.HasPrefix(sig, prefix) {
+		return errors.New("malformed signature header")
+	}
+	want, err := hex.DecodeString(strings.TrimPrefix(sig, prefix))
+	if err != nil {
+		return errors.New("malformed signature header")
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	got := mac.Sum(nil)
+	if !hmac.Equal(got, want) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerifyDelivery(t *testing.T) {
+	body := []byte(`{"hello":"world"}`)
+	secret := "s3kr3t"
+
+	sha256Sig := func() string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}()
+	sha1Sig := func() string {
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write(body)
+		return "sha1=" + hex.EncodeToString(mac.Sum(nil))
+	}()
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		secret  string
+		wantErr bool
+	}{
+		{"no secret configured", map[string]string{"X-Hub-Signature-256": sha256Sig}, "", true},
+		{"no recognized header", map[string]string{}, secret, true},
+		{"valid sha256 signature", map[string]string{"X-Hub-Signature-256": sha256Sig}, secret, false},
+		{"invalid sha256 signature", map[string]string{"X-Hub-Signature-256": "sha256=deadbeef"}, secret, true},
+		{"valid sha1 signature", map[string]string{"X-Hub-Signature": sha1Sig}, secret, false},
+		{"valid gitlab token", map[string]string{"X-Gitlab-Token": secret}, secret, false},
+		{"invalid gitlab token", map[string]string{"X-Gitlab-Token": "wrong"}, secret, true},
+		{"valid bitbucket uuid", map[string]string{"X-Hook-Uuid": secret}, secret, false},
+		{"invalid bitbucket uuid", map[string]string{"X-Hook-Uuid": "wrong"}, secret, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := verifyDelivery(Request{Headers: c.headers, Body: body}, c.secret)
+			if (err != nil) != c.wantErr {
+				t.Errorf("verifyDelivery() err = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyHMACMalformed(t *testing.T) {
+	if err := verifyHMAC(sha256.New, "not-prefixed", "sha256=", "secret", []byte("body")); err == nil {
+		t.Error("expected error for signature missing prefix")
+	}
+	if err := verifyHMAC(sha256.New, "sha256=not-hex", "sha256=", "secret", []byte("body")); err == nil {
+		t.Error("expected error for non-hex signature")
+	}
+}